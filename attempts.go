@@ -0,0 +1,77 @@
+package lbsql
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Attempt is a single connect attempt recorded by WithAttemptRecorder.
+type Attempt struct {
+	// Name is the name of the connector that was attempted.
+	Name string
+	// Err is the error the attempt failed with, or nil if it succeeded.
+	Err error
+	// Duration is how long the attempt took.
+	Duration time.Duration
+}
+
+type attemptsKey struct{}
+
+// attemptRecorder appends attempts to a caller's slice, and to the recorder
+// it was nested in if there is one. It has a mutex since ConnectN connects
+// concurrently.
+type attemptRecorder struct {
+	mu       sync.Mutex
+	attempts *[]Attempt
+	parent   *attemptRecorder
+}
+
+// WithAttemptRecorder returns a context that makes Connect append each
+// connector it attempts to attempts, in order. This makes the attempts
+// available when Connect is called by database/sql, for example through
+// sql.DB.Conn. The slice must not be read until Connect has returned. Attempts
+// are still recorded by recorders set on parent contexts.
+func WithAttemptRecorder(ctx context.Context, attempts *[]Attempt) context.Context {
+	r := &attemptRecorder{attempts: attempts, parent: attemptRecorderFromContext(ctx)}
+	return context.WithValue(ctx, attemptsKey{}, r)
+}
+
+// attemptRecorderFromContext returns the recorder set by WithAttemptRecorder,
+// or nil if there isn't one.
+func attemptRecorderFromContext(ctx context.Context) *attemptRecorder {
+	r, _ := ctx.Value(attemptsKey{}).(*attemptRecorder)
+	return r
+}
+
+// record appends an attempt. It does nothing if r is nil.
+func (r *attemptRecorder) record(a Attempt) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	*r.attempts = append(*r.attempts, a)
+	r.mu.Unlock()
+
+	r.parent.record(a)
+}
+
+// Baggage keys set by RoutingBaggage.
+const (
+	BaggageConnector = "lbsql.connector"
+	BaggageAttempts  = "lbsql.attempts"
+)
+
+// RoutingBaggage returns the name of the connector that served a Connect call
+// and the number of attempts it took, from the attempts recorded with
+// WithAttemptRecorder, keyed for use as trace baggage members so downstream
+// spans can see which backend served the request. The connector is omitted if
+// every attempt failed.
+func RoutingBaggage(attempts []Attempt) map[string]string {
+	members := map[string]string{BaggageAttempts: strconv.Itoa(len(attempts))}
+	if n := len(attempts); n > 0 && attempts[n-1].Err == nil {
+		members[BaggageConnector] = attempts[n-1].Name
+	}
+	return members
+}
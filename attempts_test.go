@@ -0,0 +1,45 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAttemptRecorder(t *testing.T) {
+	errDown := errors.New("down")
+	b := NewBalancer(WithStrategy(nameStrategy{}))
+	b.Add("a", failConnector{errDown})
+	b.Add("b", pingConnector{})
+	db := sql.OpenDB(b)
+	defer db.Close()
+
+	var attempts []Attempt
+	ctx := WithAttemptRecorder(context.Background(), &attempts)
+	c, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if len(attempts) != 2 {
+		t.Fatalf("expected 2 attempts; got %+v", attempts)
+	}
+	if a := attempts[0]; a.Name != "a" || a.Err != errDown {
+		t.Fatalf("expected the failed attempt first; got %+v", a)
+	}
+	if a := attempts[1]; a.Name != "b" || a.Err != nil {
+		t.Fatalf("expected the successful attempt second; got %+v", a)
+	}
+
+	want := map[string]string{BaggageConnector: "b", BaggageAttempts: "2"}
+	if got := RoutingBaggage(attempts); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected baggage %+v; got %+v", want, got)
+	}
+	want = map[string]string{BaggageAttempts: "1"}
+	if got := RoutingBaggage(attempts[:1]); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected no connector after a failure; got %+v", got)
+	}
+}
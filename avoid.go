@@ -0,0 +1,61 @@
+package lbsql
+
+import (
+	"time"
+)
+
+// WithFailureCache deprioritizes connectors for ttl after they fail to
+// connect, so the next Connect doesn't attempt them again straight away
+// during a blip. Like TemporarilyAvoid the connectors are still attempted
+// once the others have failed. It's meant for TTLs far shorter than the
+// cooldown of WithCircuitBreaker.
+func WithFailureCache(ttl time.Duration) Option {
+	return func(b *Balancer) {
+		b.failureCache = ttl
+	}
+}
+
+// deprioritized ranks how late a candidate is attempted, regardless of the
+// strategy.
+func deprioritized(info ConnectorInfo) int {
+	switch {
+	case info.Avoided:
+		return 2
+	case info.RecentlyFailed:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// rankOrder sorts candidates by their rank, lowest first.
+type rankOrder struct {
+	candidates []ConnectorInfo
+	rank       []int
+}
+
+func (o rankOrder) Len() int           { return len(o.candidates) }
+func (o rankOrder) Less(i, j int) bool { return o.rank[i] < o.rank[j] }
+func (o rankOrder) Swap(i, j int) {
+	o.candidates[i], o.candidates[j] = o.candidates[j], o.candidates[i]
+	o.rank[i], o.rank[j] = o.rank[j], o.rank[i]
+}
+
+// TemporarilyAvoid deprioritizes the named connector for d. Unlike an
+// ejection the connector is still attempted, but only after every other
+// connector has failed. This is useful when the application sees errors from
+// a backend outside of connecting, such as failed queries.
+func (b *Balancer) TemporarilyAvoid(name string, d time.Duration) {
+	c, ok := b.lookup(name)
+	if !ok {
+		return
+	}
+	until := b.now().Add(d)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if until.After(c.mu.avoidUntil) {
+		c.mu.avoidUntil = until
+	}
+}
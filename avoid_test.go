@@ -0,0 +1,60 @@
+package lbsql
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTemporarilyAvoid(t *testing.T) {
+	now := time.Unix(100, 0)
+	b := NewBalancer(WithPreferred("a"))
+	b.now = func() time.Time { return now }
+	b.Add("a", pingConnector{})
+	b.Add("b", pingConnector{})
+	b.Add("c", pingConnector{})
+
+	b.TemporarilyAvoid("a", time.Minute)
+	for i := 0; i < 20; i++ {
+		if names := candidateNames(t, b); names[2] != "a" {
+			t.Fatalf("expected a to be attempted last; got %+v", names)
+		}
+	}
+	if info := b.Describe()[0]; !info.Avoided {
+		t.Fatalf("expected a to be avoided; got %+v", info)
+	}
+
+	now = now.Add(time.Minute)
+	if names := candidateNames(t, b); names[0] != "a" {
+		t.Fatalf("expected a to be preferred again; got %+v", names)
+	}
+}
+
+func TestFailureCache(t *testing.T) {
+	now := time.Unix(100, 0)
+	b := NewBalancer(WithPreferred("a"), WithFailureCache(time.Second))
+	b.now = func() time.Time { return now }
+	b.Add("a", errConnector{})
+	b.Add("b", pingConnector{})
+	b.Add("c", pingConnector{})
+
+	if name := connectedName(t, b, context.Background()); name == "a" {
+		t.Fatal("expected failover from a")
+	}
+	for i := 0; i < 20; i++ {
+		if names := candidateNames(t, b); names[2] != "a" {
+			t.Fatalf("expected a to be attempted last; got %+v", names)
+		}
+	}
+
+	// Avoided connectors go after recently failed ones.
+	b.TemporarilyAvoid("b", time.Minute)
+	if names := candidateNames(t, b); names[1] != "a" || names[2] != "b" {
+		t.Fatalf("expected a before the avoided b; got %+v", names)
+	}
+
+	now = now.Add(time.Second)
+	if names := candidateNames(t, b); names[0] != "a" {
+		t.Fatalf("expected a to be preferred again after the TTL; got %+v", names)
+	}
+}
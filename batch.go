@@ -0,0 +1,137 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MultiAddError is returned by AddBatch when some of the connectors couldn't be
+// built. Unless WithAtomicBatches is set, the connectors that were built have
+// still been added.
+type MultiAddError struct {
+	// Errors are the build errors by connector name.
+	Errors map[string]error
+	// RolledBack is whether none of the batch was added because of the
+	// errors, see WithAtomicBatches.
+	RolledBack bool
+}
+
+// Names returns the names of the connectors that failed in sorted order.
+func (e *MultiAddError) Names() []string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e *MultiAddError) Error() string {
+	var msgs []string
+	for _, name := range e.Names() {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", name, e.Errors[name]))
+	}
+	return fmt.Sprintf("lbsql: failed to add %d connectors: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the build errors in name order.
+func (e *MultiAddError) Unwrap() []error {
+	var errs []error
+	for _, name := range e.Names() {
+		errs = append(errs, e.Errors[name])
+	}
+	return errs
+}
+
+// WithAtomicBatches makes AddBatch add none of the connectors in a batch if
+// any of them fail to build.
+func WithAtomicBatches(atomic bool) Option {
+	return func(b *Balancer) {
+		b.atomicBatches = atomic
+	}
+}
+
+// AddBatch builds and adds a connector for each entry, keyed by name. If some
+// of the connectors fail to build it returns a *MultiAddError listing them.
+// The rest are added in a single step, so Connect sees either all of them or
+// none.
+func (b *Balancer) AddBatch(builders map[string]func() (driver.Connector, error)) error {
+	built := map[string]driver.Connector{}
+	errs := map[string]error{}
+	for name, build := range builders {
+		c, err := build()
+		if err != nil {
+			errs[name] = err
+			continue
+		}
+		built[name] = c
+	}
+
+	b.mu.Lock()
+	if err := b.waitUnfrozenLocked(); err != nil {
+		b.mu.Unlock()
+		return err
+	}
+	for name, c := range built {
+		if err := b.duplicateLocked(name, c); err != nil {
+			errs[name] = err
+			delete(built, name)
+		}
+	}
+	for name, err := range b.duplicatesIn(built) {
+		errs[name] = err
+		delete(built, name)
+	}
+	if len(errs) > 0 && b.atomicBatches {
+		b.mu.Unlock()
+		return &MultiAddError{Errors: errs, RolledBack: true}
+	}
+	for name, c := range built {
+		b.insertLocked(name, c)
+	}
+	b.publishLocked()
+	b.mu.Unlock()
+
+	if len(errs) > 0 {
+		return &MultiAddError{Errors: errs}
+	}
+	return nil
+}
+
+// NewBalancerFromDSNs returns a Balancer with a connector for each DSN, keyed
+// by name, opened with d. If d implements driver.DriverContext its
+// OpenConnector is used, otherwise each connection is opened with d.Open. The
+// balancer is returned even if some of the DSNs fail, along with a
+// *MultiAddError listing them.
+func NewBalancerFromDSNs(d driver.Driver, dsns map[string]string, opts ...Option) (*Balancer, error) {
+	b := NewBalancer(opts...)
+	builders := make(map[string]func() (driver.Connector, error), len(dsns))
+	for name, dsn := range dsns {
+		dsn := dsn
+		builders[name] = func() (driver.Connector, error) {
+			if dc, ok := d.(driver.DriverContext); ok {
+				return dc.OpenConnector(dsn)
+			}
+			return dsnConnector{driver: d, dsn: dsn}, nil
+		}
+	}
+	return b, b.AddBatch(builders)
+}
+
+// dsnConnector is a driver.Connector for drivers that don't implement
+// driver.DriverContext.
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
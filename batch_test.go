@@ -0,0 +1,65 @@
+package lbsql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// dsnDriver is a driver.DriverContext that rejects DSNs starting with "bad".
+type dsnDriver struct{}
+
+func (dsnDriver) Open(string) (driver.Conn, error) { return pingConn{}, nil }
+
+func (dsnDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	if strings.HasPrefix(dsn, "bad") {
+		return nil, errors.New("invalid dsn")
+	}
+	return pingConnector{}, nil
+}
+
+func TestNewBalancerFromDSNs(t *testing.T) {
+	dsns := map[string]string{
+		"a": "host=a",
+		"b": "bad host",
+		"c": "host=c",
+		"d": "bad",
+	}
+	b, err := NewBalancerFromDSNs(dsnDriver{}, dsns)
+	var addErr *MultiAddError
+	if !errors.As(err, &addErr) {
+		t.Fatalf("expected *MultiAddError; got %+v", err)
+	}
+	if names := addErr.Names(); !reflect.DeepEqual(names, []string{"b", "d"}) || addErr.RolledBack {
+		t.Fatalf("expected b and d to fail; got %+v", addErr)
+	}
+	names := b.ConnectorNames()
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"a", "c"}) {
+		t.Fatalf("expected valid connectors to be added; got %+v", names)
+	}
+
+	b, err = NewBalancerFromDSNs(dsnDriver{}, dsns, WithAtomicBatches(true))
+	if !errors.As(err, &addErr) || !addErr.RolledBack {
+		t.Fatalf("expected rolled back *MultiAddError; got %+v", err)
+	}
+	if n := b.Count(); n != 0 {
+		t.Fatalf("expected no connectors to be added; got %d", n)
+	}
+
+	b, err = NewBalancerFromDSNs(driverOnly{}, dsns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := b.Count(); n != 4 {
+		t.Fatalf("expected 4 connectors; got %d", n)
+	}
+}
+
+// driverOnly is a driver.Driver that doesn't implement driver.DriverContext.
+type driverOnly struct{}
+
+func (driverOnly) Open(string) (driver.Conn, error) { return pingConn{}, nil }
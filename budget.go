@@ -0,0 +1,38 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrAttemptBudgetExhausted is returned by Connect when the context's attempt
+// budget has been used up, see WithAttemptBudget.
+var ErrAttemptBudgetExhausted = errors.New("lbsql: connect attempt budget exhausted")
+
+type attemptBudgetKey struct{}
+
+// WithAttemptBudget returns a context that allows at most n connect attempts,
+// shared across every Connect call made with it or a context derived from
+// it. Once the budget is used up Connect fails with ErrAttemptBudgetExhausted
+// instead of attempting more connectors.
+func WithAttemptBudget(ctx context.Context, n int) context.Context {
+	budget := &atomic.Int64{}
+	budget.Store(int64(n))
+	return context.WithValue(ctx, attemptBudgetKey{}, budget)
+}
+
+// takeAttempt uses one attempt from the context's budget, returning
+// ErrAttemptBudgetExhausted, wrapping the attempt errors so far, if there are
+// none left.
+func takeAttempt(ctx context.Context, errs *ConnectError) error {
+	budget, ok := ctx.Value(attemptBudgetKey{}).(*atomic.Int64)
+	if !ok || budget.Add(-1) >= 0 {
+		return nil
+	}
+	if len(errs.Errors) > 0 {
+		return fmt.Errorf("%w: %w", ErrAttemptBudgetExhausted, errs)
+	}
+	return ErrAttemptBudgetExhausted
+}
@@ -0,0 +1,36 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAttemptBudget(t *testing.T) {
+	b := NewBalancer(WithStrategy(nameStrategy{}))
+	b.Add("a", failConnector{err: errors.New("a failed")})
+	b.Add("b", pingConnector{})
+	b.Add("c", failConnector{err: errors.New("c failed")})
+
+	ctx := WithAttemptBudget(context.Background(), 3)
+	if _, err := b.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	b.Remove("b")
+	_, err := b.Connect(ctx)
+	if !errors.Is(err, ErrAttemptBudgetExhausted) {
+		t.Fatalf("expected %+v; got %+v", ErrAttemptBudgetExhausted, err)
+	}
+	var connErr *ConnectError
+	if !errors.As(err, &connErr) || len(connErr.Errors) != 1 || connErr.Errors[0].Name != "a" {
+		t.Fatalf("expected only a to be attempted; got %+v", err)
+	}
+	if info := b.Describe()[1]; info.Failures != 0 {
+		t.Fatalf("expected c to not be attempted; got %+v", info)
+	}
+
+	if _, err := b.Connect(ctx); err != ErrAttemptBudgetExhausted {
+		t.Fatalf("expected %+v; got %+v", ErrAttemptBudgetExhausted, err)
+	}
+}
@@ -0,0 +1,182 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+)
+
+// ErrAtCapacity is returned when a connector already has as many connections
+// open as allowed by SetMaxOpen. Connect fails over to the next connector
+// without counting it as a failed connect.
+var ErrAtCapacity = errors.New("lbsql: connector is at capacity")
+
+// SetMaxOpen limits the number of connections that can be open from the named
+// connector at once, including connections being dialed. Zero removes the
+// limit.
+func (b *Balancer) SetMaxOpen(name string, n int) {
+	c, ok := b.lookup(name)
+	if !ok {
+		return
+	}
+	if n < 0 {
+		n = 0
+	}
+
+	b.updateConnector(c, func() {
+		c.mu.maxOpen = n
+		if b.autoWeight && n > 0 {
+			c.mu.weight = n
+			c.mu.ramp = nil
+		}
+	})
+}
+
+// WithAutoWeightFromCapacity makes SetMaxOpen also set the connector's weight
+// to its limit, so a connector allowed 100 connections gets five times the
+// traffic of one allowed 20 with WeightedStrategy. Removing the limit leaves
+// the weight as it was, and SetWeight can still override it.
+func WithAutoWeightFromCapacity(auto bool) Option {
+	return func(b *Balancer) {
+		b.autoWeight = auto
+	}
+}
+
+// reserve reserves a connection slot for a dial, returning ErrAtCapacity if
+// the connector is at capacity. If the connector's breaker is half-open the
+// dial is its probe, which is reported by probe, and ErrProbeInFlight is
+// returned if another dial is already probing it. The slot is released once
+// the dial finishes.
+func (c *connector) reserve() (probe bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.mu.maxOpen > 0 && c.mu.open+c.mu.dialing >= c.mu.maxOpen {
+		return false, ErrAtCapacity
+	}
+	if c.mu.breaker == BreakerHalfOpen {
+		if c.mu.probing {
+			return false, ErrProbeInFlight
+		}
+		c.mu.probing = true
+		probe = true
+	}
+	c.mu.dialing++
+	return probe, nil
+}
+
+// unreserve releases a slot taken by reserve without dialing.
+func (c *connector) unreserve(probe bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mu.dialing--
+	if probe {
+		c.mu.probing = false
+	}
+}
+
+// FreeCapacity returns how many more connections the connector can open.
+// Connectors without a limit have unlimited capacity.
+func (i ConnectorInfo) FreeCapacity() int {
+	if i.MaxOpen <= 0 {
+		return math.MaxInt
+	}
+	if free := i.MaxOpen - i.Open; free > 0 {
+		return free
+	}
+	return 0
+}
+
+// MostFreeCapacityStrategy attempts the connectors with the most free capacity
+// first, see SetMaxOpen. Unlike picking the connector with the fewest open
+// connections this accounts for connectors having different limits.
+// Connectors without a limit are attempted first and ties are broken at
+// random.
+type MostFreeCapacityStrategy struct{}
+
+// Order sorts the candidates by free capacity.
+func (s MostFreeCapacityStrategy) Order(ctx context.Context, candidates []ConnectorInfo) {
+	shuffle(randFromContext(ctx), candidates)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return s.Less(candidates[i], candidates[j])
+	})
+}
+
+// Less ranks connectors with more free capacity first.
+func (MostFreeCapacityStrategy) Less(a, b ConnectorInfo) bool {
+	return a.FreeCapacity() > b.FreeCapacity()
+}
+
+// ErrAtTotalCapacity is returned by Connect when the balancer has as many
+// connections open as allowed by SetMaxTotalOpen and WithFailFastAtMaxTotal
+// is set.
+var ErrAtTotalCapacity = errors.New("lbsql: balancer is at its total connection limit")
+
+// SetMaxTotalOpen limits the number of connections that can be open across
+// every connector in the balancer, for example when one balancer backs several
+// sql.DBs. Once the limit is reached Connect waits for a connection to be
+// closed, bounded by the context, unless WithFailFastAtMaxTotal is set. Zero
+// or less removes the limit.
+func (b *Balancer) SetMaxTotalOpen(n int) {
+	b.total.Lock()
+	defer b.total.Unlock()
+
+	b.total.max = n
+	b.notifyTotalLocked()
+}
+
+// WithFailFastAtMaxTotal makes Connect fail with ErrAtTotalCapacity instead
+// of waiting when the limit set by SetMaxTotalOpen has been reached.
+func WithFailFastAtMaxTotal(failFast bool) Option {
+	return func(b *Balancer) {
+		b.failFastAtMaxTotal = failFast
+	}
+}
+
+// acquireTotal takes a slot from the total connection limit for a Connect
+// call, waiting for one to be released if needed.
+func (b *Balancer) acquireTotal(ctx context.Context) error {
+	for {
+		b.total.Lock()
+		if b.total.max <= 0 || b.total.open < b.total.max {
+			b.total.open++
+			b.total.Unlock()
+			return nil
+		}
+		if b.failFastAtMaxTotal {
+			b.total.Unlock()
+			return ErrAtTotalCapacity
+		}
+		if b.total.released == nil {
+			b.total.released = make(chan struct{})
+		}
+		released := b.total.released
+		b.total.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// releaseTotal releases a slot taken by acquireTotal.
+func (b *Balancer) releaseTotal() {
+	b.total.Lock()
+	defer b.total.Unlock()
+
+	b.total.open--
+	b.notifyTotalLocked()
+}
+
+// notifyTotalLocked wakes up the Connect calls waiting in acquireTotal. The
+// total mutex must be held.
+func (b *Balancer) notifyTotalLocked() {
+	if b.total.released != nil {
+		close(b.total.released)
+		b.total.released = nil
+	}
+}
@@ -0,0 +1,145 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetMaxOpen(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", pingConnector{})
+	b.SetMaxOpen("a", 2)
+
+	var conns []driver.Conn
+	for i := 0; i < 2; i++ {
+		c, err := b.Connect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns = append(conns, c)
+	}
+	if _, err := b.Connect(context.Background()); !errors.Is(err, ErrAtCapacity) {
+		t.Fatalf("expected %+v; got %+v", ErrAtCapacity, err)
+	}
+	if info := b.Describe()[0]; info.Failures != 0 || info.Health != Healthy {
+		t.Fatalf("expected being at capacity to not count as a failure; got %+v", info)
+	}
+
+	conns[0].Close()
+	if _, err := b.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMostFreeCapacityStrategy(t *testing.T) {
+	b := NewBalancer(WithStrategy(MostFreeCapacityStrategy{}))
+	// small has the fewest open connections but big has the most headroom.
+	for name, limits := range map[string][2]int{
+		"small":  {4, 1},
+		"big":    {100, 60},
+		"medium": {50, 20},
+	} {
+		b.Add(name, pingConnector{})
+		b.SetMaxOpen(name, limits[0])
+		c, _ := b.lookup(name)
+		c.mu.open = limits[1]
+	}
+
+	for i := 0; i < 10; i++ {
+		names := candidateNames(t, b)
+		if names[0] != "big" || names[1] != "medium" || names[2] != "small" {
+			t.Fatalf("expected order by headroom; got %+v", names)
+		}
+	}
+	if name := connectedName(t, b, context.Background()); name != "big" {
+		t.Fatalf("expected big; got %s", name)
+	}
+}
+
+func TestAutoWeightFromCapacity(t *testing.T) {
+	b := NewBalancer(WithStrategy(WeightedStrategy{}), WithAutoWeightFromCapacity(true))
+	b.Add("big", pingConnector{})
+	b.Add("small", pingConnector{})
+	b.SetMaxOpen("big", 100)
+	b.SetMaxOpen("small", 20)
+
+	picked := map[string]int{}
+	for i := 0; i < 1200; i++ {
+		c, err := b.Connect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		picked[c.(ConnNamer).ConnectorName()]++
+		c.Close()
+	}
+	// big should get ~1000 of the connects and small ~200.
+	if picked["big"] < 900 || picked["small"] < 100 {
+		t.Fatalf("expected traffic in a 5:1 ratio; got %+v", picked)
+	}
+}
+
+func TestSetMaxTotalOpen(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", pingConnector{})
+	b.Add("b", pingConnector{})
+	b.SetMaxTotalOpen(2)
+	ctx := context.Background()
+
+	var conns []driver.Conn
+	for i := 0; i < 2; i++ {
+		c, err := b.Connect(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns = append(conns, c)
+	}
+
+	done := make(chan error)
+	go func() {
+		c, err := b.Connect(ctx)
+		if err == nil {
+			c.Close()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("expected Connect to wait at the limit; got %+v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	conns[0].Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	b.Connect(ctx)
+	if _, err := b.Connect(timeout); err != context.DeadlineExceeded {
+		t.Fatalf("expected %+v; got %+v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestFailFastAtMaxTotal(t *testing.T) {
+	b := NewBalancer(WithFailFastAtMaxTotal(true))
+	b.Add("a", pingConnector{})
+	b.SetMaxTotalOpen(1)
+
+	c, err := b.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Connect(context.Background()); err != ErrAtTotalCapacity {
+		t.Fatalf("expected %+v; got %+v", ErrAtTotalCapacity, err)
+	}
+	c.Close()
+	b.SetMaxTotalOpen(0)
+	for i := 0; i < 3; i++ {
+		if _, err := b.Connect(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
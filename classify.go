@@ -0,0 +1,61 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// Error categories returned by DefaultErrorClassifier.
+const (
+	CategoryTimeout = "timeout"
+	CategoryRefused = "refused"
+	CategoryAuth    = "auth"
+	CategoryDNS     = "dns"
+	CategoryOther   = "other"
+)
+
+// WithErrorClassifier sets the function used to put connect errors into
+// categories, which are counted per connector in ConnectorInfo. The default
+// is DefaultErrorClassifier.
+func WithErrorClassifier(classify func(error) string) Option {
+	return func(b *Balancer) {
+		b.classify = classify
+	}
+}
+
+// DefaultErrorClassifier puts an error into one of the timeout, refused, auth,
+// dns or other categories. Errors that don't have a well known type are matched
+// on their message.
+func DefaultErrorClassifier(err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return CategoryDNS
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return CategoryTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return CategoryTimeout
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return CategoryRefused
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "timeout"), strings.Contains(msg, "timed out"):
+		return CategoryTimeout
+	case strings.Contains(msg, "connection refused"):
+		return CategoryRefused
+	case strings.Contains(msg, "authentication"), strings.Contains(msg, "password"),
+		strings.Contains(msg, "access denied"), strings.Contains(msg, "permission denied"):
+		return CategoryAuth
+	case strings.Contains(msg, "no such host"):
+		return CategoryDNS
+	}
+	return CategoryOther
+}
@@ -0,0 +1,63 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+type failConnector struct {
+	err error
+}
+
+func (c failConnector) Connect(context.Context) (driver.Conn, error) { return nil, c.err }
+func (failConnector) Driver() driver.Driver                          { return nil }
+
+func TestDefaultErrorClassifier(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{context.DeadlineExceeded, CategoryTimeout},
+		{fmt.Errorf("dial: %w", context.DeadlineExceeded), CategoryTimeout},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, CategoryRefused},
+		{errors.New("dial tcp: connection refused"), CategoryRefused},
+		{&net.DNSError{Err: "no such host", Name: "db"}, CategoryDNS},
+		{errors.New("pq: password authentication failed"), CategoryAuth},
+		{errors.New("boom"), CategoryOther},
+	}
+	for _, c := range cases {
+		if got := DefaultErrorClassifier(c.err); got != c.want {
+			t.Errorf("DefaultErrorClassifier(%v) = %q; expected %q", c.err, got, c.want)
+		}
+	}
+}
+
+func TestErrorCategories(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", failConnector{err: context.DeadlineExceeded})
+	b.Add("b", failConnector{err: errors.New("access denied for user")})
+	for i := 0; i < 2; i++ {
+		b.Connect(context.Background())
+	}
+
+	infos := b.Describe()
+	if want := map[string]int64{CategoryTimeout: 2}; !reflect.DeepEqual(infos[0].Categories, want) {
+		t.Fatalf("expected %+v; got %+v", want, infos[0].Categories)
+	}
+	if want := map[string]int64{CategoryAuth: 2}; !reflect.DeepEqual(infos[1].Categories, want) {
+		t.Fatalf("expected %+v; got %+v", want, infos[1].Categories)
+	}
+
+	b = NewBalancer(WithErrorClassifier(func(error) string { return "custom" }))
+	b.Add("a", errConnector{})
+	b.Connect(context.Background())
+	if want := map[string]int64{"custom": 1}; !reflect.DeepEqual(b.Describe()[0].Categories, want) {
+		t.Fatalf("expected %+v; got %+v", want, b.Describe()[0].Categories)
+	}
+}
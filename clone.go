@@ -0,0 +1,78 @@
+package lbsql
+
+import "time"
+
+// Clone returns a new balancer with the same options, middleware and
+// connectors as b, including each connector's weight, tier, role and other
+// settings, but with fresh stats, health and breaker state. The clone shares
+// the underlying driver.Connector instances with b and can be changed without
+// affecting it. The built-in strategies are copied so the clone doesn't share
+// their state, such as the call count of DeterministicSpreadStrategy, while
+// other strategies and values passed to options, such as a SharedHealth, are
+// shared.
+func (b *Balancer) Clone() *Balancer {
+	clone := NewBalancer(b.opts...)
+	clone.strategy = cloneStrategy(clone.strategy)
+
+	b.mu.Lock()
+	middleware := append([]ConnectMiddleware(nil), b.mu.middleware...)
+	now := b.now()
+	for name, c := range b.mu.connectors {
+		cc := clone.insertLocked(name, c.Connector)
+		c.mu.Lock()
+		c.copySettingsLocked(cc, now)
+		c.mu.Unlock()
+	}
+	clone.publishLocked()
+	b.mu.Unlock()
+
+	clone.ordered.Store(b.ordered.Load())
+	b.total.Lock()
+	clone.total.max = b.total.max
+	b.total.Unlock()
+	if len(middleware) > 0 {
+		clone.Use(middleware...)
+	}
+	return clone
+}
+
+// copySettingsLocked copies the settings of c, but none of its runtime state,
+// to dst. The mutex of c must be held.
+func (c *connector) copySettingsLocked(dst *connector, now time.Time) {
+	dst.mu.weight = c.mu.weight
+	if c.mu.ramp != nil {
+		r := *c.mu.ramp
+		dst.mu.ramp = &r
+	}
+	if !dst.mu.warming {
+		dst.mu.reportedWeight = c.weightLocked(now)
+	}
+	dst.mu.tier = c.mu.tier
+	dst.mu.role = c.mu.role
+	dst.mu.metricTag = c.mu.metricTag
+	dst.mu.tags = c.mu.tags
+	dst.mu.dialTimeout = c.mu.dialTimeout
+	dst.mu.maxOpen = c.mu.maxOpen
+	dst.mu.retryPolicy = c.mu.retryPolicy
+	dst.mu.initStmts = c.mu.initStmts
+	dst.mu.probe = c.mu.probe
+}
+
+// cloneStrategy returns a copy of s without its state if it's a built-in
+// strategy with state, and s otherwise.
+func cloneStrategy(s Strategy) Strategy {
+	switch s := s.(type) {
+	case *DeterministicSpreadStrategy:
+		return &DeterministicSpreadStrategy{}
+	case StickyStrategy:
+		if s.Fallback != nil {
+			s.Fallback = cloneStrategy(s.Fallback)
+		}
+		return s
+	case *StickyStrategy:
+		copied := cloneStrategy(*s).(StickyStrategy)
+		return &copied
+	default:
+		return s
+	}
+}
@@ -0,0 +1,77 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClone(t *testing.T) {
+	var calls int
+	b := NewBalancer(WithStrategy(WeightedStrategy{}), WithCircuitBreaker(1, time.Minute))
+	b.Use(func(next ConnectFunc) ConnectFunc {
+		return func(ctx context.Context) (driver.Conn, error) {
+			calls++
+			return next(ctx)
+		}
+	})
+	b.AddTiered("primary", pingConnector{}, 0, 3)
+	b.AddTiered("standby", failConnector{errors.New("down")}, 1, 1)
+	b.SetMaxOpen("primary", 10)
+	// Trip the standby's breaker so the original has runtime state.
+	b.Connect(WithConnectorHint(context.Background(), "standby"))
+
+	clone := b.Clone()
+	if calls != 1 {
+		t.Fatalf("expected 1 middleware call; got %d", calls)
+	}
+	clone.SetWeight("primary", 7)
+
+	infos := map[string]ConnectorInfo{}
+	for _, info := range clone.Describe() {
+		infos[info.Name] = info
+	}
+	if p := infos["primary"]; p.Weight != 7 || p.MaxOpen != 10 || p.Tier != 0 || p.Connects != 0 {
+		t.Fatalf("expected the primary's settings without its stats; got %+v", p)
+	}
+	if s := infos["standby"]; s.Tier != 1 || s.Health != Healthy || s.Failures != 0 {
+		t.Fatalf("expected a fresh standby; got %+v", s)
+	}
+	if w := b.Describe()[0].Weight; w != 3 {
+		t.Fatalf("expected the original weight to be unchanged; got %d", w)
+	}
+
+	if name := connectedName(t, clone, context.Background()); name != "primary" {
+		t.Fatalf("expected the clone to follow the tiers; got %s", name)
+	}
+	if calls != 2 {
+		t.Fatalf("expected the clone to run the middleware; got %d calls", calls)
+	}
+	if _, ok := clone.strategy.(WeightedStrategy); !ok {
+		t.Fatalf("expected the strategy to be copied; got %T", clone.strategy)
+	}
+}
+
+func TestCloneStrategyState(t *testing.T) {
+	b := NewBalancer(WithStrategy(&DeterministicSpreadStrategy{}))
+	b.Add("a", pingConnector{})
+	b.Add("b", pingConnector{})
+	b.Add("c", pingConnector{})
+	connectedName(t, b, context.Background())
+	clone := b.Clone()
+
+	// The clone starts its own rotation without advancing the original's.
+	if name := connectedName(t, clone, context.Background()); name != "a" {
+		t.Fatalf("expected the clone to start at a; got %s", name)
+	}
+	if name := connectedName(t, b, context.Background()); name != "b" {
+		t.Fatalf("expected the original to continue at b; got %s", name)
+	}
+
+	sticky := NewBalancer(WithStrategy(StickyStrategy{Fallback: &DeterministicSpreadStrategy{}}))
+	if s := sticky.Clone().strategy.(StickyStrategy); s.Fallback == sticky.strategy.(StickyStrategy).Fallback {
+		t.Fatal("expected the fallback strategy to be copied")
+	}
+}
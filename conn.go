@@ -0,0 +1,238 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var _ driver.Conn = &conn{}
+var _ driver.Pinger = &conn{}
+var _ driver.ExecerContext = &conn{}
+var _ driver.QueryerContext = &conn{}
+var _ driver.ConnPrepareContext = &conn{}
+var _ driver.ConnBeginTx = &conn{}
+var _ driver.SessionResetter = &conn{}
+var _ driver.Validator = &conn{}
+var _ driver.NamedValueChecker = &conn{}
+var _ ConnNamer = &conn{}
+
+// ConnNamer is implemented by the connections returned by the balancer. Query
+// middleware can type assert a driver.Conn to it to find out which connector
+// the connection came from.
+type ConnNamer interface {
+	// ConnectorName returns the name of the connector the connection was
+	// made with.
+	ConnectorName() string
+}
+
+// conn wraps a driver.Conn returned by a connector so the balancer can track
+// how many connections are open to it. The optional driver interfaces are
+// forwarded to the underlying connection, falling back to the same behavior
+// database/sql has when they aren't implemented.
+type conn struct {
+	driver.Conn
+
+	b          *Balancer
+	c          *connector
+	generation uint64
+	opened     time.Time
+	closeOnce  sync.Once
+	// lastUsed is when the connection was last used in unix nanoseconds, see
+	// WithIdleProbe.
+	lastUsed atomic.Int64
+}
+
+// InvalidateConnections marks every connection currently open to the named
+// connector as invalid, so database/sql discards them instead of reusing them
+// and dials new connections through the balancer. This is useful before a
+// backend is restarted. Connections made afterwards aren't affected.
+func (b *Balancer) InvalidateConnections(name string) {
+	if c, ok := b.lookup(name); ok {
+		c.generation.Add(1)
+	}
+}
+
+// InFlight returns the number of connections from the named connector that
+// are open and haven't been closed yet.
+func (b *Balancer) InFlight(name string) int {
+	c, ok := b.lookup(name)
+	if !ok {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.mu.open
+}
+
+// InFlightTotal returns the number of open connections across all connectors.
+func (b *Balancer) InFlightTotal() int {
+	total := 0
+	for _, c := range *b.connectors.Load() {
+		c.mu.Lock()
+		total += c.mu.open
+		c.mu.Unlock()
+	}
+	return total
+}
+
+// UnwrapConn returns the connection made by the connector if c is a
+// connection returned by the balancer, and c otherwise. This gives access to
+// driver specific methods the balancer doesn't forward, for example from
+// sql.Conn.Raw.
+func UnwrapConn(c driver.Conn) driver.Conn {
+	if wrapped, ok := c.(*conn); ok {
+		return wrapped.Conn
+	}
+	return c
+}
+
+// Close closes the underlying connection and marks it as closed.
+func (c *conn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() {
+		c.b.closed(c.c, c.opened)
+	})
+	return err
+}
+
+// ConnectorName implements ConnNamer.
+func (c *conn) ConnectorName() string {
+	return c.c.name()
+}
+
+// Ping implements driver.Pinger.
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.touch()
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, query, args)
+	}
+	e, ok := c.Conn.(driver.Execer)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	dargs, err := namedValueToValue(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return e.Exec(query, dargs)
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.touch()
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, query, args)
+	}
+	q, ok := c.Conn.(driver.Queryer)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	dargs, err := namedValueToValue(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return q.Query(query, dargs)
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	c.touch()
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		stmt.Close()
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// BeginTx implements driver.ConnBeginTx.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.touch()
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(0) {
+		return nil, errors.New("lbsql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("lbsql: driver does not support read-only transactions")
+	}
+	tx, err := c.Conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// ResetSession implements driver.SessionResetter.
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements driver.Validator. Connections are invalid once
+// InvalidateConnections has been called for their connector, or when they fail
+// the idle probe, see WithIdleProbe.
+func (c *conn) IsValid() bool {
+	if c.c != nil && c.c.generation.Load() != c.generation {
+		return false
+	}
+	if !c.probeIdle() {
+		return false
+	}
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func namedValueToValue(named []driver.NamedValue) ([]driver.Value, error) {
+	dargs := make([]driver.Value, len(named))
+	for n, param := range named {
+		if len(param.Name) > 0 {
+			return nil, errors.New("lbsql: driver does not support the use of Named Parameters")
+		}
+		dargs[n] = param.Value
+	}
+	return dargs, nil
+}
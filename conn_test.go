@@ -0,0 +1,349 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type execConn struct {
+	pingConn
+	query string
+}
+
+func (c *execConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	c.query = query
+	return driver.RowsAffected(len(args)), nil
+}
+
+func TestConnForwarding(t *testing.T) {
+	ctx := context.Background()
+
+	underlying := &execConn{}
+	c := &conn{Conn: underlying}
+	res, err := c.ExecContext(ctx, "foo", []driver.NamedValue{{Ordinal: 1, Value: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 || underlying.query != "foo" {
+		t.Fatalf("expected exec to be forwarded; got %d, %q", n, underlying.query)
+	}
+	if _, err := c.ExecContext(ctx, "foo", []driver.NamedValue{{Name: "a", Value: 1}}); err == nil {
+		t.Fatalf("expected error for named parameters")
+	}
+
+	if _, err := c.QueryContext(ctx, "foo", nil); err != driver.ErrSkip {
+		t.Fatalf("expected %+v; got %+v", driver.ErrSkip, err)
+	}
+	if err := c.CheckNamedValue(&driver.NamedValue{}); err != driver.ErrSkip {
+		t.Fatalf("expected %+v; got %+v", driver.ErrSkip, err)
+	}
+	if !c.IsValid() {
+		t.Fatalf("expected conn to be valid")
+	}
+	if _, err := c.BeginTx(ctx, driver.TxOptions{ReadOnly: true}); err == nil {
+		t.Fatalf("expected error for read only transaction")
+	}
+}
+
+func TestInvalidateConnections(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", pingConnector{})
+
+	old, err := b.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.InvalidateConnections("a")
+	b.InvalidateConnections("missing")
+
+	if old.(driver.Validator).IsValid() {
+		t.Fatalf("expected invalidated connection to not be valid")
+	}
+	fresh, err := b.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fresh.(driver.Validator).IsValid() {
+		t.Fatalf("expected new connection to be valid")
+	}
+}
+
+func TestConnNamer(t *testing.T) {
+	b := NewBalancer()
+	b.Add("replica", pingConnector{})
+
+	c, err := b.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	namer, ok := c.(ConnNamer)
+	if !ok {
+		t.Fatalf("expected connection to implement ConnNamer")
+	}
+	if name := namer.ConnectorName(); name != "replica" {
+		t.Fatalf("expected replica; got %q", name)
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	b := NewBalancer(WithStrategy(nameStrategy{}))
+	b.Add("a", pingConnector{})
+	b.Add("b", pingConnector{})
+
+	var conns []driver.Conn
+	for i := 0; i < 3; i++ {
+		c, err := b.Connect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns = append(conns, c)
+	}
+	if n := b.InFlight("a"); n != 3 {
+		t.Fatalf("expected 3 in flight; got %d", n)
+	}
+
+	conns[0].Close()
+	// Closing twice only counts once.
+	conns[0].Close()
+	if n := b.InFlight("a"); n != 2 {
+		t.Fatalf("expected 2 in flight; got %d", n)
+	}
+	if n := b.InFlight("missing"); n != 0 {
+		t.Fatalf("expected 0 in flight for a missing connector; got %d", n)
+	}
+
+	b.Remove("a")
+	b.Add("a", pingConnector{})
+	if _, err := b.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := b.InFlightTotal(); n != 1 {
+		t.Fatalf("expected 1 in flight in total; got %d", n)
+	}
+}
+
+// recConn records the calls made to it. The types embedding it each
+// implement one of the optional driver interfaces.
+type recConn struct {
+	calls *[]string
+}
+
+func (c recConn) record(call string) { *c.calls = append(*c.calls, call) }
+
+func (c recConn) Prepare(string) (driver.Stmt, error) {
+	c.record("Prepare")
+	return nil, nil
+}
+func (c recConn) Close() error { return nil }
+func (c recConn) Begin() (driver.Tx, error) {
+	c.record("Begin")
+	return nil, nil
+}
+
+type recPinger struct{ recConn }
+
+func (c recPinger) Ping(context.Context) error {
+	c.record("Ping")
+	return nil
+}
+
+type recExecerContext struct{ recConn }
+
+func (c recExecerContext) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	c.record("ExecContext")
+	return nil, nil
+}
+
+type recExecer struct{ recConn }
+
+func (c recExecer) Exec(string, []driver.Value) (driver.Result, error) {
+	c.record("Exec")
+	return nil, nil
+}
+
+type recQueryerContext struct{ recConn }
+
+func (c recQueryerContext) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	c.record("QueryContext")
+	return nil, nil
+}
+
+type recQueryer struct{ recConn }
+
+func (c recQueryer) Query(string, []driver.Value) (driver.Rows, error) {
+	c.record("Query")
+	return nil, nil
+}
+
+type recPrepareContext struct{ recConn }
+
+func (c recPrepareContext) PrepareContext(context.Context, string) (driver.Stmt, error) {
+	c.record("PrepareContext")
+	return nil, nil
+}
+
+type recBeginTx struct{ recConn }
+
+func (c recBeginTx) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.record("BeginTx")
+	return nil, nil
+}
+
+type recSessionResetter struct{ recConn }
+
+func (c recSessionResetter) ResetSession(context.Context) error {
+	c.record("ResetSession")
+	return driver.ErrBadConn
+}
+
+type recValidator struct{ recConn }
+
+func (c recValidator) IsValid() bool {
+	c.record("IsValid")
+	return false
+}
+
+type recNamedValueChecker struct{ recConn }
+
+func (c recNamedValueChecker) CheckNamedValue(*driver.NamedValue) error {
+	c.record("CheckNamedValue")
+	return nil
+}
+
+var errInvalid = errors.New("invalid")
+
+// TestConnInterfaceMatrix checks every optional interface of the wrapper is
+// forwarded when the underlying connection implements it, and otherwise
+// behaves the way database/sql does for connections that don't.
+func TestConnInterfaceMatrix(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name string
+		// impl builds a connection implementing the interface.
+		impl func(recConn) driver.Conn
+		call func(*conn) error
+		// want and wantErr are the calls recorded and error returned with
+		// impl, and fallback and fallbackErr are the same without it.
+		want        []string
+		wantErr     error
+		fallback    []string
+		fallbackErr error
+	}{
+		{
+			name: "Pinger",
+			impl: func(c recConn) driver.Conn { return recPinger{c} },
+			call: func(c *conn) error { return c.Ping(ctx) },
+			want: []string{"Ping"},
+		},
+		{
+			name:        "ExecerContext",
+			impl:        func(c recConn) driver.Conn { return recExecerContext{c} },
+			call:        func(c *conn) error { _, err := c.ExecContext(ctx, "q", nil); return err },
+			want:        []string{"ExecContext"},
+			fallbackErr: driver.ErrSkip,
+		},
+		{
+			name:        "Execer",
+			impl:        func(c recConn) driver.Conn { return recExecer{c} },
+			call:        func(c *conn) error { _, err := c.ExecContext(ctx, "q", nil); return err },
+			want:        []string{"Exec"},
+			fallbackErr: driver.ErrSkip,
+		},
+		{
+			name:        "QueryerContext",
+			impl:        func(c recConn) driver.Conn { return recQueryerContext{c} },
+			call:        func(c *conn) error { _, err := c.QueryContext(ctx, "q", nil); return err },
+			want:        []string{"QueryContext"},
+			fallbackErr: driver.ErrSkip,
+		},
+		{
+			name:        "Queryer",
+			impl:        func(c recConn) driver.Conn { return recQueryer{c} },
+			call:        func(c *conn) error { _, err := c.QueryContext(ctx, "q", nil); return err },
+			want:        []string{"Query"},
+			fallbackErr: driver.ErrSkip,
+		},
+		{
+			name:     "ConnPrepareContext",
+			impl:     func(c recConn) driver.Conn { return recPrepareContext{c} },
+			call:     func(c *conn) error { _, err := c.PrepareContext(ctx, "q"); return err },
+			want:     []string{"PrepareContext"},
+			fallback: []string{"Prepare"},
+		},
+		{
+			name:     "ConnBeginTx",
+			impl:     func(c recConn) driver.Conn { return recBeginTx{c} },
+			call:     func(c *conn) error { _, err := c.BeginTx(ctx, driver.TxOptions{}); return err },
+			want:     []string{"BeginTx"},
+			fallback: []string{"Begin"},
+		},
+		{
+			name:    "SessionResetter",
+			impl:    func(c recConn) driver.Conn { return recSessionResetter{c} },
+			call:    func(c *conn) error { return c.ResetSession(ctx) },
+			want:    []string{"ResetSession"},
+			wantErr: driver.ErrBadConn,
+		},
+		{
+			name: "Validator",
+			impl: func(c recConn) driver.Conn { return recValidator{c} },
+			call: func(c *conn) error {
+				if !c.IsValid() {
+					return errInvalid
+				}
+				return nil
+			},
+			want:    []string{"IsValid"},
+			wantErr: errInvalid,
+		},
+		{
+			name:        "NamedValueChecker",
+			impl:        func(c recConn) driver.Conn { return recNamedValueChecker{c} },
+			call:        func(c *conn) error { return c.CheckNamedValue(&driver.NamedValue{}) },
+			want:        []string{"CheckNamedValue"},
+			fallbackErr: driver.ErrSkip,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			c := &conn{Conn: tc.impl(recConn{calls: &calls})}
+			if err := tc.call(c); err != tc.wantErr {
+				t.Fatalf("expected error %+v; got %+v", tc.wantErr, err)
+			}
+			if !reflect.DeepEqual(calls, tc.want) {
+				t.Fatalf("expected calls %+v; got %+v", tc.want, calls)
+			}
+
+			calls = nil
+			c = &conn{Conn: recConn{calls: &calls}}
+			if err := tc.call(c); err != tc.fallbackErr {
+				t.Fatalf("expected fallback error %+v; got %+v", tc.fallbackErr, err)
+			}
+			if !reflect.DeepEqual(calls, tc.fallback) {
+				t.Fatalf("expected fallback calls %+v; got %+v", tc.fallback, calls)
+			}
+		})
+	}
+}
+
+func TestUnwrapConn(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", connectorFunc(func(context.Context) (driver.Conn, error) { return &execConn{}, nil }))
+
+	c, err := b.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := UnwrapConn(c).(*execConn); !ok {
+		t.Fatalf("expected the underlying connection; got %T", UnwrapConn(c))
+	}
+	raw := &execConn{}
+	if UnwrapConn(raw) != raw {
+		t.Fatal("expected connections from elsewhere to be returned as is")
+	}
+}
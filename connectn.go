@@ -0,0 +1,53 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync"
+)
+
+// WithAllOrNothingConnectN makes ConnectN close the connections it opened and
+// return none of them if any of its connects fail. By default it returns the
+// connections that succeeded along with the error.
+func WithAllOrNothingConnectN(all bool) Option {
+	return func(b *Balancer) {
+		b.connectNAll = all
+	}
+}
+
+// ConnectN opens n connections in parallel with Connect, for example to warm
+// up a pool at startup. It returns the connections that succeeded and the
+// errors from the ones that didn't joined together, see
+// WithAllOrNothingConnectN. It returns nothing if n isn't positive.
+func (b *Balancer) ConnectN(ctx context.Context, n int) ([]driver.Conn, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	conns := make([]driver.Conn, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conns[i], errs[i] = b.Connect(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	opened := conns[:0]
+	for _, c := range conns {
+		if c != nil {
+			opened = append(opened, c)
+		}
+	}
+	err := errors.Join(errs...)
+	if err != nil && b.connectNAll {
+		for _, c := range opened {
+			c.Close()
+		}
+		return nil, err
+	}
+	return opened, err
+}
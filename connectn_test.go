@@ -0,0 +1,58 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestConnectN(t *testing.T) {
+	b := NewBalancer(WithStrategy(&DeterministicSpreadStrategy{}))
+	b.Add("a", pingConnector{})
+	b.Add("b", pingConnector{})
+	b.Add("c", pingConnector{})
+
+	conns, err := b.ConnectN(context.Background(), 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conns) != 9 {
+		t.Fatalf("expected 9 connections; got %d", len(conns))
+	}
+	for _, info := range b.Describe() {
+		if info.Open != 3 {
+			t.Fatalf("expected connections to be spread evenly; got %+v", info)
+		}
+	}
+}
+
+func TestConnectNNonPositive(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", pingConnector{})
+	for _, n := range []int{0, -1} {
+		if conns, err := b.ConnectN(context.Background(), n); conns != nil || err != nil {
+			t.Fatalf("expected nothing for %d; got %+v and %+v", n, conns, err)
+		}
+	}
+}
+
+func TestConnectNPartial(t *testing.T) {
+	for _, all := range []bool{false, true} {
+		b := NewBalancer(WithAllOrNothingConnectN(all))
+		c := &closeConnector{name: "a"}
+		b.Add("a", c)
+		b.SetMaxOpen("a", 2)
+
+		conns, err := b.ConnectN(context.Background(), 3)
+		if !errors.Is(err, ErrAtCapacity) {
+			t.Fatalf("all=%t: expected %+v; got %+v", all, ErrAtCapacity, err)
+		}
+		if all {
+			if len(conns) != 0 || c.closed != 2 {
+				t.Fatalf("expected the successful connections to be closed; got %d conns and %d closed", len(conns), c.closed)
+			}
+		} else if len(conns) != 2 || c.closed != 0 {
+			t.Fatalf("expected the successful connections to be returned; got %d conns and %d closed", len(conns), c.closed)
+		}
+	}
+}
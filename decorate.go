@@ -0,0 +1,35 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+)
+
+// AddWithContextFunc adds a driver.Connector to the balancer whose Connect is
+// called with the context returned by decorate. This lets values such as query
+// tags or tenant IDs be injected for a particular backend.
+func (b *Balancer) AddWithContextFunc(name string, c driver.Connector, decorate func(ctx context.Context) context.Context) {
+	b.Add(name, decoratedConnector{Connector: c, decorate: decorate})
+}
+
+// decoratedConnector is a driver.Connector that decorates the context before
+// connecting.
+type decoratedConnector struct {
+	driver.Connector
+
+	decorate func(ctx context.Context) context.Context
+}
+
+// Connect connects with the decorated context.
+func (c decoratedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.Connector.Connect(c.decorate(ctx))
+}
+
+// Close closes the underlying connector if it implements io.Closer.
+func (c decoratedConnector) Close() error {
+	if closer, ok := c.Connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+)
+
+type tenantKey struct{}
+
+func TestAddWithContextFunc(t *testing.T) {
+	b := NewBalancer()
+
+	var tenant interface{}
+	b.AddWithContextFunc("a", connectorFunc(func(ctx context.Context) (driver.Conn, error) {
+		tenant = ctx.Value(tenantKey{})
+		return pingConn{}, nil
+	}), func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, tenantKey{}, "acme")
+	})
+
+	if _, err := b.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if tenant != "acme" {
+		t.Fatalf("expected connector to observe the decorated context; got %v", tenant)
+	}
+}
@@ -0,0 +1,39 @@
+package lbsql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+)
+
+var defaultBalancer struct {
+	once sync.Once
+	b    *Balancer
+}
+
+// Default returns the default Balancer used by the package level functions.
+// It's created the first time it's needed.
+func Default() *Balancer {
+	defaultBalancer.once.Do(func() {
+		defaultBalancer.b = NewBalancer()
+	})
+	return defaultBalancer.b
+}
+
+// Register registers the default Balancer with database/sql under driverName,
+// so it can be used with sql.Open(driverName, ""). Passing a connector name
+// instead of "" prefers that connector, see Balancer.OpenConnector. Like
+// sql.Register it panics if called twice with the same name.
+func Register(driverName string) {
+	sql.Register(driverName, Default())
+}
+
+// Add adds a driver.Connector to the default Balancer.
+func Add(name string, c driver.Connector) {
+	Default().Add(name, c)
+}
+
+// Remove removes a connector from the default Balancer.
+func Remove(name string) {
+	Default().Remove(name)
+}
@@ -0,0 +1,35 @@
+package lbsql
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDefaultBalancer(t *testing.T) {
+	if Default() != Default() {
+		t.Fatalf("expected the same default balancer")
+	}
+
+	driverName := fmt.Sprintf("lbsql-test-%d", time.Now().UnixNano())
+	Register(driverName)
+	Add("default-test", pingConnector{})
+	defer Remove("default-test")
+
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, info := range Default().Describe() {
+		if info.Name == "default-test" && info.Connects == 1 {
+			return
+		}
+	}
+	t.Fatalf("expected the connection to go through the added connector; got %+v", Default().Describe())
+}
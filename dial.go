@@ -0,0 +1,46 @@
+package lbsql
+
+import "context"
+
+// WithMaxConcurrentDials limits the named connector to k dials in progress at
+// once, so a reconnect storm doesn't overwhelm the backend. Further dials
+// wait for a slot, bounded by the context, instead of racing. Unlike
+// SetMaxOpen this doesn't limit established connections. It applies whenever
+// a connector with the name is added.
+func WithMaxConcurrentDials(name string, k int) Option {
+	return func(b *Balancer) {
+		if b.maxDials == nil {
+			b.maxDials = map[string]int{}
+		}
+		b.maxDials[name] = k
+	}
+}
+
+// acquireDial waits for a dial slot if c limits concurrent dials, recording
+// the wait in its queue wait histogram.
+func (b *Balancer) acquireDial(ctx context.Context, c *connector) error {
+	if c.dials == nil {
+		return nil
+	}
+	start := b.now()
+	var err error
+	select {
+	case c.dials <- struct{}{}:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	wait := b.now().Sub(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mu.queueWait.observe(wait.Seconds())
+	return err
+}
+
+// releaseDial releases the slot taken by acquireDial.
+func (c *connector) releaseDial() {
+	if c.dials != nil {
+		<-c.dials
+	}
+}
@@ -0,0 +1,100 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyConnector records the most dials it has seen in progress at once.
+type concurrencyConnector struct {
+	active, max atomic.Int64
+}
+
+func (c *concurrencyConnector) Connect(context.Context) (driver.Conn, error) {
+	n := c.active.Add(1)
+	defer c.active.Add(-1)
+	for {
+		max := c.max.Load()
+		if n <= max || c.max.CompareAndSwap(max, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return pingConn{}, nil
+}
+
+func (*concurrencyConnector) Driver() driver.Driver { return nil }
+
+func TestMaxConcurrentDials(t *testing.T) {
+	b := NewBalancer(WithMaxConcurrentDials("a", 1))
+	a := &concurrencyConnector{}
+	b.Add("a", a)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := b.Connect(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if max := a.max.Load(); max != 1 {
+		t.Fatalf("expected dials to be serialized; got %d at once", max)
+	}
+
+	// Queued dials give up when the context is done.
+	a.max.Store(0)
+	b.mu.connectors["a"].dials <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.Connect(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %+v; got %+v", context.DeadlineExceeded, err)
+	}
+	if info := b.Describe()[0]; info.Failures != 0 || a.max.Load() != 0 {
+		t.Fatalf("expected the queued dial to not be attempted; got %+v", info)
+	}
+}
+
+func TestQueueWait(t *testing.T) {
+	b := NewBalancer(WithMaxConcurrentDials("a", 1))
+	dialing := make(chan struct{}, 2)
+	b.Add("a", connectorFunc(func(context.Context) (driver.Conn, error) {
+		dialing <- struct{}{}
+		time.Sleep(20 * time.Millisecond)
+		return pingConn{}, nil
+	}))
+
+	var wg sync.WaitGroup
+	connect := func() {
+		defer wg.Done()
+		if _, err := b.Connect(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}
+	wg.Add(2)
+	go connect()
+	<-dialing
+	go connect()
+	wg.Wait()
+
+	info := b.Describe()[0]
+	if info.QueueWait.Count != 2 {
+		t.Fatalf("expected 2 queue waits; got %+v", info.QueueWait)
+	}
+	// The first connect didn't wait, the second waited for most of the
+	// first's dial.
+	if wait := info.QueueWait.Sum; wait < 0.01 || wait > info.Latency.Seconds()*2 {
+		t.Fatalf("expected a single dial's worth of queue wait; got %fs with a latency of %s", wait, info.Latency)
+	}
+	if info.QueueWait.Counts[0] != 1 {
+		t.Fatalf("expected one connect to not wait; got %+v", info.QueueWait)
+	}
+}
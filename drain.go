@@ -0,0 +1,65 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrDraining is returned by Connect once Drain has been called.
+var ErrDraining = errors.New("lbsql: balancer is draining")
+
+// drainPollInterval is how often Drain checks for open connections.
+const drainPollInterval = 10 * time.Millisecond
+
+// Drain shuts the balancer down. New Connect calls fail with ErrDraining, and
+// once every open connection has been closed, or the context is done, the
+// connectors that implement io.Closer are closed. It returns the context's
+// error if it stopped waiting early, joined with any errors from closing the
+// connectors.
+func (b *Balancer) Drain(ctx context.Context) error {
+	b.mu.Lock()
+	b.draining.Store(true)
+	b.mu.Unlock()
+
+	var errs []error
+	if err := b.waitIdle(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	for _, c := range *b.connectors.Load() {
+		if closer, ok := c.Connector.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// waitIdle waits until no connections are open or being dialed.
+func (b *Balancer) waitIdle(ctx context.Context) error {
+	t := time.NewTicker(drainPollInterval)
+	defer t.Stop()
+
+	for {
+		busy := false
+		for _, c := range *b.connectors.Load() {
+			c.mu.Lock()
+			busy = c.mu.open > 0 || c.mu.dialing > 0
+			c.mu.Unlock()
+			if busy {
+				break
+			}
+		}
+		if !busy {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	var closed int
+	built := false
+	b := NewBalancer(WithStrategy(nameStrategy{}))
+	b.Add("a", closerConnector{closed: &closed})
+	b.AddWithContextFunc("b", closerConnector{closed: &closed}, func(ctx context.Context) context.Context {
+		return ctx
+	})
+	b.AddLazy("c", func() (driver.Connector, error) {
+		return closerConnector{closed: &closed}, nil
+	})
+	b.AddLazy("d", func() (driver.Connector, error) {
+		built = true
+		return closerConnector{closed: &closed}, nil
+	})
+	lazy, err := b.Connect(WithConnectorHint(context.Background(), "c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lazy.Close()
+	built = false
+
+	c, err := b.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drained := make(chan error)
+	go func() {
+		drained <- b.Drain(context.Background())
+	}()
+
+	for !b.draining.Load() {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := b.Connect(context.Background()); err != ErrDraining {
+		t.Fatalf("expected %+v; got %+v", ErrDraining, err)
+	}
+	select {
+	case <-drained:
+		t.Fatal("expected Drain to wait for the open connection")
+	case <-time.After(3 * drainPollInterval):
+	}
+
+	c.Close()
+	if err := <-drained; err != nil {
+		t.Fatal(err)
+	}
+	// The wrapped connectors are closed too, except for d which was never
+	// built.
+	if closed != 3 {
+		t.Fatalf("expected three connectors to be closed; got %d", closed)
+	}
+	if built {
+		t.Fatal("expected d to not be built by Drain")
+	}
+}
+
+func TestDrainContext(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", pingConnector{})
+	if _, err := b.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %+v; got %+v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestDrainStopsInFlightConnect(t *testing.T) {
+	dials := 0
+	b := NewBalancer()
+	b.Add("a", connectorFunc(func(context.Context) (driver.Conn, error) {
+		dials++
+		return pingConn{}, nil
+	}))
+	c, _ := b.lookup("a")
+	if err := b.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A Connect that loaded the connectors before Drain doesn't dial.
+	if _, err := b.connect(context.Background(), c); err != ErrDraining {
+		t.Fatalf("expected %+v; got %+v", ErrDraining, err)
+	}
+	if dials != 0 {
+		t.Fatalf("expected no dials; got %d", dials)
+	}
+	if info := b.Describe()[0]; info.Failures != 0 {
+		t.Fatalf("expected the skipped dial to not count as a failure; got %+v", info)
+	}
+}
@@ -0,0 +1,98 @@
+package lbsql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrDuplicateConnector is returned when a connector is added under a new
+// name while it's already in the balancer under another, if
+// WithRejectDuplicateConnectors is set.
+var ErrDuplicateConnector = errors.New("lbsql: connector already added")
+
+// WithRejectDuplicateConnectors rejects adding a connector that's already in
+// the balancer under a different name, which would otherwise get it picked
+// twice as often. Calls that return an error, such as AddBatch, return
+// ErrDuplicateConnector, while the others, such as Add, leave the balancer
+// unchanged. Connectors are compared with ==, so connectors that aren't
+// comparable are never seen as duplicates.
+func WithRejectDuplicateConnectors(reject bool) Option {
+	return func(b *Balancer) {
+		b.rejectDups = reject
+	}
+}
+
+// duplicateLocked returns ErrDuplicateConnector if c is already in the
+// balancer under a name other than name. The balancer's mutex must be held.
+func (b *Balancer) duplicateLocked(name string, c driver.Connector) error {
+	if !b.rejectDups {
+		return nil
+	}
+	for other, conn := range b.mu.connectors {
+		if other != name && sameConnector(conn.Connector, c) {
+			return fmt.Errorf("%w: %q is already added as %q", ErrDuplicateConnector, name, other)
+		}
+	}
+	return nil
+}
+
+// duplicatesIn returns an ErrDuplicateConnector error for each name in
+// connectors whose connector is also in connectors under a name that sorts
+// before it.
+func (b *Balancer) duplicatesIn(connectors map[string]driver.Connector) map[string]error {
+	if !b.rejectDups {
+		return nil
+	}
+	names := make([]string, 0, len(connectors))
+	for name := range connectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errs := map[string]error{}
+	for i, name := range names {
+		for _, other := range names[:i] {
+			if sameConnector(connectors[name], connectors[other]) {
+				errs[name] = fmt.Errorf("%w: %q is already added as %q", ErrDuplicateConnector, name, other)
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// duplicateIn returns ErrDuplicateConnector if a connector is in connectors
+// under more than one name.
+func (b *Balancer) duplicateIn(connectors map[string]driver.Connector) error {
+	errs := b.duplicatesIn(connectors)
+	if len(errs) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return errs[names[0]]
+}
+
+// AddUnique is like Add but returns ErrNameExists instead of replacing the
+// connector if the name is already in the balancer, leaving the existing
+// connector untouched. This catches names that are accidentally repeated in
+// configuration.
+func (b *Balancer) AddUnique(name string, c driver.Connector) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// checkAddLocked can wait for the balancer to be unfrozen, releasing its
+	// mutex, so the name is checked after it.
+	if err := b.checkAddLocked(name, c); err != nil {
+		return err
+	}
+	if _, ok := b.mu.connectors[name]; ok {
+		return fmt.Errorf("%w: %q", ErrNameExists, name)
+	}
+	b.addLocked(name, c)
+	return nil
+}
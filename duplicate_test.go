@@ -0,0 +1,111 @@
+package lbsql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRejectDuplicateConnectors(t *testing.T) {
+	b := NewBalancer(WithRejectDuplicateConnectors(true))
+	c := &closeConnector{name: "a"}
+	b.Add("a", c)
+	b.Add("b", c)
+	b.AddAuto(c)
+	if names := b.ConnectorNames(); len(names) != 1 || names[0] != "a" {
+		t.Fatalf("expected the duplicates to be rejected; got %+v", names)
+	}
+
+	// Re-adding under the same name replaces it as usual.
+	b.Add("a", c)
+	if b.Count() != 1 {
+		t.Fatalf("expected 1 connector; got %d", b.Count())
+	}
+
+	err := b.AddBatch(map[string]func() (driver.Connector, error){
+		"c": func() (driver.Connector, error) { return c, nil },
+		"d": func() (driver.Connector, error) { return &closeConnector{name: "d"}, nil },
+	})
+	var multi *MultiAddError
+	if !errors.As(err, &multi) || !errors.Is(multi.Errors["c"], ErrDuplicateConnector) || len(multi.Errors) != 1 {
+		t.Fatalf("expected c to be rejected as a duplicate; got %+v", err)
+	}
+	if b.Count() != 2 {
+		t.Fatalf("expected d to be added; got %d connectors", b.Count())
+	}
+}
+
+func TestAddUnique(t *testing.T) {
+	b := NewBalancer()
+	if err := b.AddUnique("a", pingConnector{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddUnique("a", errConnector{}); !errors.Is(err, ErrNameExists) {
+		t.Fatalf("expected %+v; got %+v", ErrNameExists, err)
+	}
+	if c := b.mu.connectors["a"].Connector; c != (pingConnector{}) {
+		t.Fatalf("expected the original connector to remain; got %+v", c)
+	}
+	if err := b.AddUnique("b", errConnector{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddUniqueFrozen(t *testing.T) {
+	b := NewBalancer()
+	b.Freeze()
+	errs := make(chan error, 2)
+	for _, c := range []driver.Connector{pingConnector{}, errConnector{}} {
+		c := c
+		go func() { errs <- b.AddUnique("a", c) }()
+	}
+	// Let both calls block on the freeze.
+	time.Sleep(20 * time.Millisecond)
+	b.Unfreeze()
+
+	var exists int
+	for i := 0; i < 2; i++ {
+		if err := <-errs; errors.Is(err, ErrNameExists) {
+			exists++
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if exists != 1 {
+		t.Fatalf("expected one call to fail with %+v; got %d", ErrNameExists, exists)
+	}
+}
+
+func TestReplaceAllRejectDuplicates(t *testing.T) {
+	b := NewBalancer(WithRejectDuplicateConnectors(true))
+	b.Add("a", pingConnector{})
+	c := &closeConnector{name: "c"}
+	err := b.ReplaceAll(map[string]driver.Connector{"b": c, "c": c})
+	if !errors.Is(err, ErrDuplicateConnector) {
+		t.Fatalf("expected %+v; got %+v", ErrDuplicateConnector, err)
+	}
+	if names := b.ConnectorNames(); len(names) != 1 || names[0] != "a" {
+		t.Fatalf("expected the set to be unchanged; got %+v", names)
+	}
+
+	if err := b.ReplaceAll(map[string]driver.Connector{"b": c, "d": &closeConnector{name: "d"}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddBatchRejectDuplicatesInBatch(t *testing.T) {
+	b := NewBalancer(WithRejectDuplicateConnectors(true))
+	c := &closeConnector{name: "c"}
+	err := b.AddBatch(map[string]func() (driver.Connector, error){
+		"a": func() (driver.Connector, error) { return c, nil },
+		"b": func() (driver.Connector, error) { return c, nil },
+	})
+	var multi *MultiAddError
+	if !errors.As(err, &multi) || !errors.Is(multi.Errors["b"], ErrDuplicateConnector) || len(multi.Errors) != 1 {
+		t.Fatalf("expected b to be rejected as a duplicate of a; got %+v", err)
+	}
+	if names := b.ConnectorNames(); len(names) != 1 || names[0] != "a" {
+		t.Fatalf("expected only a to be added; got %+v", names)
+	}
+}
@@ -0,0 +1,112 @@
+package lbsql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AttemptError is the error from a single connect attempt.
+type AttemptError struct {
+	// Name is the name of the connector that was attempted.
+	Name string
+	Err  error
+	// Category is the category of Err, see WithErrorClassifier.
+	Category string
+}
+
+func (e AttemptError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+func (e AttemptError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectError is returned by Connect when every connector it attempted
+// failed. It unwraps to the reported attempt errors, so errors.Is and
+// errors.As can be used to match any of them.
+type ConnectError struct {
+	// Errors are the reported attempt errors, oldest first.
+	Errors []AttemptError
+	// Elided is the number of older attempt errors that weren't reported, see
+	// WithMaxReportedErrors.
+	Elided int
+
+	max       int
+	classify  func(error) string
+	retryable bool
+	// attempts counts the attempts of each connector with a retry policy,
+	// and exhausted is the connectors that can't be attempted again, see
+	// SetRetryPolicy.
+	attempts  map[*connector]int
+	exhausted map[*connector]bool
+}
+
+// WithMaxReportedErrors limits the ConnectError returned by Connect to the n
+// most recent attempt errors, counting the rest in Elided. This bounds the
+// size of the error with many connectors or set retries. By default every
+// attempt error is reported.
+func WithMaxReportedErrors(n int) Option {
+	return func(b *Balancer) {
+		b.maxReportedErrors = n
+	}
+}
+
+// add records an attempt error, dropping the oldest one once the limit is
+// reached.
+func (e *ConnectError) add(name string, err error) {
+	if e.max > 0 && len(e.Errors) >= e.max {
+		copy(e.Errors, e.Errors[1:])
+		e.Errors = e.Errors[:len(e.Errors)-1]
+		e.Elided++
+	}
+	category := CategoryOther
+	if e.classify != nil {
+		category = e.classify(err)
+	}
+	e.Errors = append(e.Errors, AttemptError{Name: name, Err: err, Category: category})
+	e.retryable = e.retryable || retryableAttempt(category, err)
+}
+
+// IsRetryable reports whether retrying later could plausibly succeed, which
+// is the case if any attempt, including elided ones, failed with a transient
+// error: a timeout, a refused connection, or a connector at capacity, being
+// probed or skipped for lack of time. Failures that are all auth errors or other
+// misconfigurations aren't retryable.
+func (e *ConnectError) IsRetryable() bool {
+	return e.retryable
+}
+
+// retryableAttempt reports whether an attempt error in category is transient.
+func retryableAttempt(category string, err error) bool {
+	switch category {
+	case CategoryTimeout, CategoryRefused:
+		return true
+	}
+	return errors.Is(err, ErrAtCapacity) || errors.Is(err, ErrProbeInFlight) ||
+		errors.Is(err, ErrDeadlineTooSoon)
+}
+
+func (e *ConnectError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("lbsql: all connect attempts failed: ")
+	for i, err := range e.Errors {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(err.Error())
+	}
+	if e.Elided > 0 {
+		fmt.Fprintf(&sb, " (and %d more)", e.Elided)
+	}
+	return sb.String()
+}
+
+func (e *ConnectError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,75 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConnectError(t *testing.T) {
+	b := NewBalancer(WithStrategy(nameStrategy{}))
+	b.Add("a", failConnector{err: errors.New("a failed")})
+	b.Add("b", failConnector{err: errors.New("b failed")})
+
+	_, err := b.Connect(context.Background())
+	var connErr *ConnectError
+	if !errors.As(err, &connErr) {
+		t.Fatalf("expected a *ConnectError; got %+v", err)
+	}
+	if len(connErr.Errors) != 2 || connErr.Errors[0].Name != "a" || connErr.Errors[1].Name != "b" || connErr.Elided != 0 {
+		t.Fatalf("expected errors from a and b; got %+v", connErr)
+	}
+	if want := "lbsql: all connect attempts failed: a: a failed; b: b failed"; err.Error() != want {
+		t.Fatalf("expected %q; got %q", want, err.Error())
+	}
+}
+
+func TestMaxReportedErrors(t *testing.T) {
+	b := NewBalancer(WithStrategy(nameStrategy{}), WithMaxReportedErrors(3))
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("conn-%03d", i)
+		b.Add(name, failConnector{err: errors.New(name)})
+	}
+
+	_, err := b.Connect(context.Background())
+	var connErr *ConnectError
+	if !errors.As(err, &connErr) {
+		t.Fatalf("expected a *ConnectError; got %+v", err)
+	}
+	if len(connErr.Errors) != 3 || connErr.Elided != 97 {
+		t.Fatalf("expected 3 errors and 97 elided; got %d and %d", len(connErr.Errors), connErr.Elided)
+	}
+	if connErr.Errors[0].Name != "conn-097" || connErr.Errors[2].Name != "conn-099" {
+		t.Fatalf("expected the most recent errors; got %+v", connErr.Errors)
+	}
+	if msg := err.Error(); strings.Count(msg, "conn-") != 6 || !strings.HasSuffix(msg, "(and 97 more)") {
+		t.Fatalf("unexpected error message %q", msg)
+	}
+}
+
+func TestConnectErrorIsRetryable(t *testing.T) {
+	b := NewBalancer(WithStrategy(nameStrategy{}), WithMaxReportedErrors(1))
+	b.Add("a", failConnector{err: context.DeadlineExceeded})
+	b.Add("b", failConnector{err: errors.New("password authentication failed")})
+
+	_, err := b.Connect(context.Background())
+	var connErr *ConnectError
+	if !errors.As(err, &connErr) {
+		t.Fatalf("expected a *ConnectError; got %+v", err)
+	}
+	// The timeout was elided but still counts.
+	if len(connErr.Errors) != 1 || connErr.Errors[0].Category != CategoryAuth {
+		t.Fatalf("expected only the auth error to be reported; got %+v", connErr.Errors)
+	}
+	if !connErr.IsRetryable() {
+		t.Fatal("expected a timeout to make the error retryable")
+	}
+
+	b.Remove("a")
+	_, err = b.Connect(context.Background())
+	if !errors.As(err, &connErr) || connErr.IsRetryable() {
+		t.Fatalf("expected auth errors alone to not be retryable; got %+v", err)
+	}
+}
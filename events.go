@@ -0,0 +1,116 @@
+package lbsql
+
+import (
+	"time"
+)
+
+// defaultRebalanceThreshold is the default relative change in effective weight
+// that triggers a RebalanceEvent.
+const defaultRebalanceThreshold = 0.2
+
+// RebalanceEvent is sent to subscribers when a connector's health changes or
+// its effective weight changes significantly, see WithRebalanceThreshold.
+type RebalanceEvent struct {
+	// Name is the name of the connector.
+	Name string
+	// Time is when the change happened.
+	Time time.Time
+
+	PrevHealth Health
+	Health     Health
+
+	PrevEffectiveWeight int
+	EffectiveWeight     int
+}
+
+// WithRebalanceThreshold sets the relative change in a connector's effective
+// weight, compared to the last RebalanceEvent for it, that triggers a new
+// event. The default is 0.2. Health changes always trigger an event.
+func WithRebalanceThreshold(threshold float64) Option {
+	return func(b *Balancer) {
+		b.rebalanceThreshold = threshold
+	}
+}
+
+// Subscribe calls fn with every RebalanceEvent until the returned cancel
+// function is called. fn is called synchronously after the change and must not
+// block.
+func (b *Balancer) Subscribe(fn func(RebalanceEvent)) (cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.mu.subscribers == nil {
+		b.mu.subscribers = map[int]func(RebalanceEvent){}
+	}
+	id := b.mu.nextSub
+	b.mu.nextSub++
+	b.mu.subscribers[id] = fn
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.mu.subscribers, id)
+	}
+}
+
+// updateConnector calls fn with the connector's mutex held and then notifies
+// subscribers if it changed the connector's routing significantly.
+func (b *Balancer) updateConnector(c *connector, fn func()) {
+	c.mu.Lock()
+	fn()
+	ev, ok := b.rebalanceEventLocked(c)
+	c.mu.Unlock()
+
+	if ok {
+		b.publish(ev)
+		if ev.Health != ev.PrevHealth {
+			b.checkAllUnhealthy()
+		}
+	}
+}
+
+// rebalanceEventLocked returns the event for the connector if its health or
+// effective weight has moved past the threshold since the last event. The
+// connector's mutex must be held.
+func (b *Balancer) rebalanceEventLocked(c *connector) (RebalanceEvent, bool) {
+	info := c.infoLocked(b.now())
+	prev := c.mu.reportedWeight
+	delta := float64(info.EffectiveWeight - prev)
+	if delta < 0 {
+		delta = -delta
+	}
+	base := float64(prev)
+	if base < 1 {
+		base = 1
+	}
+	if info.Health == c.mu.reportedHealth && delta/base < b.rebalanceThreshold {
+		return RebalanceEvent{}, false
+	}
+
+	ev := RebalanceEvent{
+		Name:                c.mu.name,
+		Time:                b.now(),
+		PrevHealth:          c.mu.reportedHealth,
+		Health:              info.Health,
+		PrevEffectiveWeight: prev,
+		EffectiveWeight:     info.EffectiveWeight,
+	}
+	c.mu.reportedHealth = info.Health
+	c.mu.reportedWeight = info.EffectiveWeight
+	return ev, true
+}
+
+// publish sends the event to all subscribers.
+func (b *Balancer) publish(ev RebalanceEvent) {
+	b.mu.Lock()
+	subscribers := make([]func(RebalanceEvent), 0, len(b.mu.subscribers))
+	for _, fn := range b.mu.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	b.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(ev)
+	}
+}
@@ -0,0 +1,56 @@
+package lbsql
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRebalanceEvents(t *testing.T) {
+	b := NewBalancer(WithCircuitBreaker(3, time.Minute))
+	b.Add("a", errConnector{})
+
+	var events []RebalanceEvent
+	cancel := b.Subscribe(func(ev RebalanceEvent) {
+		events = append(events, ev)
+	})
+
+	for i := 0; i < 5; i++ {
+		b.Connect(context.Background())
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event; got %+v", events)
+	}
+	ev := events[0]
+	if ev.Name != "a" || ev.PrevHealth != Healthy || ev.Health != Ejected ||
+		ev.PrevEffectiveWeight != 1 || ev.EffectiveWeight != 0 {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+
+	cancel()
+	b.SetWeight("a", 10)
+	if len(events) != 1 {
+		t.Fatalf("expected no events after cancel; got %+v", events)
+	}
+}
+
+func TestRebalanceEventsThreshold(t *testing.T) {
+	b := NewBalancer(WithRebalanceThreshold(0.5))
+	b.Add("a", testConnector{})
+	b.SetWeight("a", 10)
+
+	var events []RebalanceEvent
+	b.Subscribe(func(ev RebalanceEvent) {
+		events = append(events, ev)
+	})
+
+	b.SetWeight("a", 12)
+	b.SetWeight("a", 14)
+	if len(events) != 0 {
+		t.Fatalf("expected small changes to not emit events; got %+v", events)
+	}
+	b.SetWeight("a", 20)
+	if len(events) != 1 || events[0].PrevEffectiveWeight != 10 || events[0].EffectiveWeight != 20 {
+		t.Fatalf("expected one event from 10 to 20; got %+v", events)
+	}
+}
@@ -0,0 +1,56 @@
+package lbsql
+
+import "expvar"
+
+// expvarConnector is the per connector entry published by PublishExpvar.
+type expvarConnector struct {
+	Connects int64  `json:"connects"`
+	Failures int64  `json:"failures"`
+	Open     int    `json:"open"`
+	Healthy  bool   `json:"healthy"`
+	Health   string `json:"health"`
+	Breaker  string `json:"breaker"`
+	// Weight and EffectiveWeight show how traffic is currently split, see
+	// ConnectorInfo.EffectiveWeight.
+	Weight          int `json:"weight"`
+	EffectiveWeight int `json:"effective_weight"`
+	// BreakerOpened is the number of times the connector's breaker opened.
+	BreakerOpened int64 `json:"breaker_opened"`
+}
+
+// expvarStats is the value published by PublishExpvar.
+type expvarStats struct {
+	Connects      int64                      `json:"connects"`
+	Failures      int64                      `json:"failures"`
+	SLOViolations int64                      `json:"slo_violations"`
+	Connectors    map[string]expvarConnector `json:"connectors"`
+}
+
+// PublishExpvar publishes the balancer's counters to expvar under name, so
+// they're served on /debug/vars. The values are read each time the variable is
+// requested. Like expvar.Publish it panics if name is already in use.
+func (b *Balancer) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		stats := b.Stats()
+		v := expvarStats{
+			Connects:      stats.Connects,
+			Failures:      stats.Failures,
+			SLOViolations: stats.SLOViolations,
+			Connectors:    map[string]expvarConnector{},
+		}
+		for _, info := range b.Describe() {
+			v.Connectors[info.Name] = expvarConnector{
+				Connects:        info.Connects,
+				Failures:        info.Failures,
+				Open:            info.Open,
+				Healthy:         info.Health == Healthy,
+				Health:          info.Health.String(),
+				Weight:          info.Weight,
+				EffectiveWeight: info.EffectiveWeight,
+				Breaker:         info.Breaker.String(),
+				BreakerOpened:   info.BreakerTransitions.Opened,
+			}
+		}
+		return v
+	}))
+}
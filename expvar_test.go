@@ -0,0 +1,58 @@
+package lbsql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	b := NewBalancer(WithStrategy(nameStrategy{}))
+	b.Add("a", failConnector{errors.New("down")})
+	b.Add("b", pingConnector{})
+	name := fmt.Sprintf("lbsql-test-%d", time.Now().UnixNano())
+	b.PublishExpvar(name)
+
+	for i := 0; i < 3; i++ {
+		connectedName(t, b, context.Background())
+	}
+
+	var got expvarStats
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Connects != 3 || got.Failures != 0 {
+		t.Fatalf("expected 3 connects; got %+v", got)
+	}
+	if a, b := got.Connectors["a"], got.Connectors["b"]; a.Failures != 3 || b.Connects != 3 || b.Open != 0 {
+		t.Fatalf("expected the per connector counters; got %+v", got.Connectors)
+	}
+}
+
+func TestPublishExpvarWeights(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", pingConnector{})
+	name := fmt.Sprintf("lbsql-test-weights-%d", time.Now().UnixNano())
+	b.PublishExpvar(name)
+	scrape := func() expvarConnector {
+		t.Helper()
+		var got expvarStats
+		if err := json.Unmarshal([]byte(expvar.Get(name).String()), &got); err != nil {
+			t.Fatal(err)
+		}
+		return got.Connectors["a"]
+	}
+
+	b.SetWeight("a", 5)
+	if got := scrape(); got.Weight != 5 || got.EffectiveWeight != 5 || got.Health != "healthy" {
+		t.Fatalf("expected a weight of 5 while healthy; got %+v", got)
+	}
+	b.SetHealth("a", false)
+	if got := scrape(); got.Weight != 5 || got.EffectiveWeight != 0 || got.Health != "ejected" {
+		t.Fatalf("expected no effective weight while ejected; got %+v", got)
+	}
+}
@@ -0,0 +1,57 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// fastPathOK reports whether Connect can use the fast path. It's only used
+// with RandomStrategy when nothing else affects the order, since then a
+// uniformly random healthy connector is as good as the first of a shuffle of
+// all of them.
+func (b *Balancer) fastPathOK(ctx context.Context) bool {
+	if _, ok := b.strategy.(RandomStrategy); !ok {
+		return false
+	}
+	if b.stableShuffle || b.topK > 0 || b.firstAvailable || b.preferred != "" ||
+		b.exploration > 0 || b.connectHook != nil || b.ordered.Load() {
+		return false
+	}
+	if _, ok := hintFromContext(ctx); ok {
+		return false
+	}
+	if _, ok := memoHintFromContext(ctx); ok {
+		return false
+	}
+	return subsetFromContext(ctx) == nil && selectorFromContext(ctx) == nil &&
+		retryMemoryFromContext(ctx) == nil
+}
+
+// connectFast attempts a single random connector without building and
+// ordering the full list of candidates, which is O(n) in the number of
+// connectors. If the connector isn't one the full path could have attempted
+// first it returns without attempting it, and if the attempt fails it returns
+// the connector so the full path can skip it.
+func (b *Balancer) connectFast(ctx context.Context, errs *ConnectError) (driver.Conn, *connector, error) {
+	connectors := *b.connectors.Load()
+	if len(connectors) == 0 {
+		return nil, nil, nil
+	}
+	now := b.now()
+	if b.outliers != nil {
+		b.outliers.maybeDetect(b, connectors, now)
+	}
+
+	c := connectors[b.rand.Intn(len(connectors))]
+	c.mu.Lock()
+	b.adoptSharedLocked(c, now)
+	first := c.availableLocked(now) && !now.Before(c.mu.avoidUntil) && !now.Before(c.mu.failedUntil)
+	name := c.mu.name
+	c.mu.Unlock()
+	if !first {
+		return nil, nil, nil
+	}
+
+	conn, err := b.try(ctx, c, name, errs)
+	return conn, c, err
+}
@@ -0,0 +1,47 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestConnectFastPath(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", pingConnector{})
+	b.Add("b", pingConnector{})
+	b.Add("bad", failConnector{errors.New("down")})
+	ctx := context.Background()
+	if !b.fastPathOK(ctx) {
+		t.Fatal("expected the fast path to be used")
+	}
+
+	// Failed fast path attempts fall back to the rest, and the healthy
+	// connectors are still picked evenly.
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		counts[connectedName(t, b, ctx)]++
+	}
+	if counts["a"] < 400 || counts["b"] < 400 {
+		t.Fatalf("expected an even spread; got %+v", counts)
+	}
+
+	if b.fastPathOK(WithConnectorHint(ctx, "a")) {
+		t.Fatal("expected hints to use the full path")
+	}
+	b.AddTiered("c", pingConnector{}, 1, 1)
+	if b.fastPathOK(ctx) {
+		t.Fatal("expected tiers to use the full path")
+	}
+}
+
+func TestConnectFastPathErrors(t *testing.T) {
+	b := NewBalancer()
+	b.Add("bad", failConnector{errors.New("down")})
+
+	_, err := b.Connect(context.Background())
+	var connectErr *ConnectError
+	if !errors.As(err, &connectErr) || len(connectErr.Errors) != 1 {
+		t.Fatalf("expected a single attempt error; got %+v", err)
+	}
+}
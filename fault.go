@@ -0,0 +1,26 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// WithFaultInjector sets a function that is called with the name of each
+// connector before it's dialed, for testing how an application copes with
+// failing backends. If it returns an error the attempt fails with it without
+// dialing the connector, and counts as a failed connect like a real one.
+func WithFaultInjector(inject func(name string) error) Option {
+	return func(b *Balancer) {
+		b.faultInjector = inject
+	}
+}
+
+// dial connects to the underlying connector unless a fault is injected.
+func (b *Balancer) dial(ctx context.Context, c *connector) (driver.Conn, error) {
+	if b.faultInjector != nil {
+		if err := b.faultInjector(c.name()); err != nil {
+			return nil, err
+		}
+	}
+	return b.enforcedConnect(ctx, c)
+}
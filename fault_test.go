@@ -0,0 +1,35 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestFaultInjector(t *testing.T) {
+	errInjected := errors.New("injected")
+	b := NewBalancer(WithStrategy(nameStrategy{}), WithFaultInjector(func(name string) error {
+		if name == "a" {
+			return errInjected
+		}
+		return nil
+	}))
+	dials := 0
+	b.Add("a", connectorFunc(func(context.Context) (driver.Conn, error) {
+		dials++
+		return pingConn{}, nil
+	}))
+	b.Add("b", pingConnector{})
+
+	if name := connectedName(t, b, context.Background()); name != "b" {
+		t.Fatalf("expected failover to b; got %q", name)
+	}
+	if dials != 0 {
+		t.Fatalf("expected a to never be dialed; got %d dials", dials)
+	}
+	info, _ := b.lookup("a")
+	if err := info.info(b.now()).LastError; err != errInjected {
+		t.Fatalf("expected the injected error to be recorded; got %+v", err)
+	}
+}
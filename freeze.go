@@ -0,0 +1,52 @@
+package lbsql
+
+import "errors"
+
+// ErrFrozen is returned by changes to the set of connectors while the balancer
+// is frozen, if WithRejectWhileFrozen is set.
+var ErrFrozen = errors.New("lbsql: connector set is frozen")
+
+// WithRejectWhileFrozen makes changes to the set of connectors fail while the
+// balancer is frozen instead of blocking until it's unfrozen. Changes that
+// return an error, such as Rename, Reset, AddBatch, TryAdd and TryRemove,
+// return ErrFrozen and the others, such as Add, AddTiered and Remove, are
+// silently ignored.
+func WithRejectWhileFrozen(reject bool) Option {
+	return func(b *Balancer) {
+		b.rejectFrozen = reject
+	}
+}
+
+// Freeze prevents the set of connectors from changing until Unfreeze is
+// called. Adding, removing, renaming and resetting connectors blocks while
+// frozen, or fails with WithRejectWhileFrozen. Connect, SetWeight and the
+// other calls that don't change the set keep working.
+func (b *Balancer) Freeze() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.mu.frozen = true
+}
+
+// Unfreeze allows the set of connectors to change again after Freeze, waking up
+// any blocked changes.
+func (b *Balancer) Unfreeze() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.mu.frozen = false
+	b.mu.thawed.Broadcast()
+}
+
+// waitUnfrozenLocked waits until the balancer isn't frozen, or returns
+// ErrFrozen if changes are rejected instead. The balancer's mutex must be
+// held.
+func (b *Balancer) waitUnfrozenLocked() error {
+	for b.mu.frozen {
+		if b.rejectFrozen {
+			return ErrFrozen
+		}
+		b.mu.thawed.Wait()
+	}
+	return nil
+}
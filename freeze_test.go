@@ -0,0 +1,78 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFreeze(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", pingConnector{})
+	b.Add("b", pingConnector{})
+	b.Freeze()
+
+	removed := make(chan struct{})
+	go func() {
+		b.Remove("a")
+		close(removed)
+	}()
+
+	select {
+	case <-removed:
+		t.Fatal("expected Remove to block while frozen")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if b.Count() != 2 {
+		t.Fatalf("expected 2 connectors while frozen; got %d", b.Count())
+	}
+	connectedName(t, b, context.Background())
+
+	b.Unfreeze()
+	<-removed
+	if b.Count() != 1 {
+		t.Fatalf("expected a to be removed after unfreezing; got %d connectors", b.Count())
+	}
+}
+
+func TestRejectWhileFrozen(t *testing.T) {
+	b := NewBalancer(WithRejectWhileFrozen(true))
+	b.Add("a", pingConnector{})
+	b.Freeze()
+
+	b.Remove("a")
+	b.Add("b", pingConnector{})
+	if err := b.TryRemove("a"); err != ErrFrozen {
+		t.Fatalf("expected %+v; got %+v", ErrFrozen, err)
+	}
+	if err := b.TryAdd("b", pingConnector{}); err != ErrFrozen {
+		t.Fatalf("expected %+v; got %+v", ErrFrozen, err)
+	}
+	if err := b.Rename("a", "c"); err != ErrFrozen {
+		t.Fatalf("expected %+v; got %+v", ErrFrozen, err)
+	}
+	if err := b.Reset(); err != ErrFrozen {
+		t.Fatalf("expected %+v; got %+v", ErrFrozen, err)
+	}
+	if names := b.ConnectorNames(); len(names) != 1 || names[0] != "a" {
+		t.Fatalf("expected only a; got %+v", names)
+	}
+
+	b.Unfreeze()
+	b.Remove("a")
+	if b.Count() != 0 {
+		t.Fatalf("expected a to be removed after unfreezing; got %d connectors", b.Count())
+	}
+}
+
+func TestTryRemove(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", pingConnector{})
+	if err := b.TryRemove("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.TryRemove("a"); !errors.Is(err, ErrConnectorNotFound) {
+		t.Fatalf("expected %+v; got %+v", ErrConnectorNotFound, err)
+	}
+}
@@ -0,0 +1,267 @@
+package lbsql
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoHealthyConnectors is returned when every connector in the balancer has
+// been ejected.
+var ErrNoHealthyConnectors = errors.New("lbsql: no healthy connectors")
+
+// Health is the health state of a connector.
+type Health int
+
+const (
+	// Healthy connectors are eligible for selection.
+	Healthy Health = iota
+	// Ejected connectors have been removed from selection by the circuit
+	// breaker, outlier detection, a failed health check or SetHealth.
+	Ejected
+	// Warming connectors have been added but haven't passed their warmup
+	// probes yet, see WithWarmup.
+	Warming
+)
+
+func (h Health) String() string {
+	switch h {
+	case Healthy:
+		return "healthy"
+	case Ejected:
+		return "ejected"
+	case Warming:
+		return "warming"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerState is the state of a connector's circuit breaker, see
+// WithCircuitBreaker.
+type BreakerState int
+
+const (
+	// BreakerClosed connectors are selected as usual.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen connectors are ejected until their cooldown has passed.
+	BreakerOpen
+	// BreakerHalfOpen connectors have passed their cooldown and are being
+	// tried again by a single probe connect at a time. A success closes the
+	// breaker and a failure opens it.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrProbeInFlight is recorded for connectors whose breaker is half-open while
+// another Connect is already probing them, so a recovering backend isn't hit
+// by every waiting Connect at once.
+var ErrProbeInFlight = errors.New("lbsql: connector is already being probed")
+
+// BreakerTransitions counts how many times a connector's circuit breaker has
+// moved into each state, which shows how much a backend is flapping.
+type BreakerTransitions struct {
+	Opened     int64
+	HalfOpened int64
+	Closed     int64
+}
+
+// BreakerState returns the state of the named connector's circuit breaker,
+// moving it to half-open if its cooldown has passed, and whether the
+// connector exists.
+func (b *Balancer) BreakerState(name string) (BreakerState, bool) {
+	c, ok := b.lookup(name)
+	if !ok {
+		return BreakerClosed, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := b.now()
+	b.adoptSharedLocked(c, now)
+	c.availableLocked(now)
+	return c.mu.breaker, true
+}
+
+// setBreakerLocked moves the connector's breaker to state, counting the
+// transition. The connector's mutex must be held.
+func (c *connector) setBreakerLocked(state BreakerState) {
+	if c.mu.breaker == state {
+		return
+	}
+	c.mu.breaker = state
+	switch state {
+	case BreakerOpen:
+		c.mu.breakerTransitions.Opened++
+	case BreakerHalfOpen:
+		c.mu.breakerTransitions.HalfOpened++
+	case BreakerClosed:
+		c.mu.breakerTransitions.Closed++
+	}
+}
+
+// WithCircuitBreaker ejects a connector from selection after the given number
+// of consecutive connect failures. Once cooldown has passed the connector is
+// attempted again, and a success returns it to selection while a failure ejects
+// it for another cooldown.
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return func(b *Balancer) {
+		b.breakerFailures = failures
+		b.breakerCooldown = cooldown
+	}
+}
+
+// WithOnAllUnhealthy sets a function that is called when the last healthy
+// connector becomes unhealthy. It's only called again after the balancer has
+// recovered, see WithOnRecovered.
+func WithOnAllUnhealthy(fn func()) Option {
+	return func(b *Balancer) {
+		b.onAllUnhealthy = fn
+	}
+}
+
+// WithOnRecovered sets a function that is called when a connector becomes
+// healthy after every connector was unhealthy, see WithOnAllUnhealthy.
+func WithOnRecovered(fn func()) Option {
+	return func(b *Balancer) {
+		b.onRecovered = fn
+	}
+}
+
+// checkAllUnhealthy calls the all unhealthy or recovered hook if the balancer
+// has moved between having no healthy connectors and having some.
+func (b *Balancer) checkAllUnhealthy() {
+	if b.onAllUnhealthy == nil && b.onRecovered == nil {
+		return
+	}
+
+	healthy := false
+	for _, c := range *b.connectors.Load() {
+		c.mu.Lock()
+		healthy = c.healthLocked() == Healthy
+		c.mu.Unlock()
+		if healthy {
+			break
+		}
+	}
+
+	if !healthy && b.allUnhealthy.CompareAndSwap(false, true) && b.onAllUnhealthy != nil {
+		b.onAllUnhealthy()
+	}
+	if healthy && b.allUnhealthy.CompareAndSwap(true, false) && b.onRecovered != nil {
+		b.onRecovered()
+	}
+}
+
+// HealthScore returns the fraction of the balancer's weight that is on
+// healthy connectors, between 0 and 1, for example for an autoscaler to react
+// to lost capacity. Ejected connectors count against the score. It's 0 if
+// there are no connectors with a weight.
+func (b *Balancer) HealthScore() float64 {
+	now := b.now()
+	// Summing in floating point can't overflow however large the weights.
+	var healthy, total float64
+	for _, c := range *b.connectors.Load() {
+		info := c.info(now)
+		healthy += float64(info.EffectiveWeight)
+		total += float64(info.Weight)
+	}
+	if total <= 0 {
+		return 0
+	}
+	return healthy / total
+}
+
+// SetHealth marks the named connector as healthy or not from an external
+// health signal, such as a monitoring sidecar. A connector marked unhealthy is
+// ejected from selection until it's marked healthy again, while marking it
+// healthy only clears the external verdict and leaves the circuit breaker and
+// other health checks in effect.
+func (b *Balancer) SetHealth(name string, healthy bool) {
+	c, ok := b.lookup(name)
+	if !ok {
+		return
+	}
+
+	b.updateConnector(c, func() {
+		c.mu.markedDown = !healthy
+	})
+}
+
+// healthLocked returns the health state of the connector. The connector's
+// mutex must be held.
+func (c *connector) healthLocked() Health {
+	if c.mu.breaker != BreakerClosed || c.mu.outlier || c.mu.probeFailed || c.mu.markedDown {
+		return Ejected
+	}
+	if c.mu.warming {
+		return Warming
+	}
+	return Healthy
+}
+
+// availableLocked reports whether the connector can be attempted, moving an
+// open breaker to half-open once its cooldown has passed. The connector's mutex
+// must be held.
+func (c *connector) availableLocked(now time.Time) bool {
+	if c.mu.breaker == BreakerOpen && !now.Before(c.mu.retryAt) {
+		c.setBreakerLocked(BreakerHalfOpen)
+	}
+	return c.mu.breaker != BreakerOpen && !c.mu.outlier && !c.mu.probeFailed && !c.mu.markedDown && !c.mu.warming
+}
+
+// recordSuccessLocked updates the connector's state after a successful
+// connect. The connector's mutex must be held.
+func (b *Balancer) recordSuccessLocked(c *connector) {
+	c.mu.connects++
+	c.mu.open++
+	c.mu.lastSuccess = b.now()
+	c.mu.recentConnects.add(c.mu.lastSuccess)
+	c.mu.windowAttempts++
+	c.mu.windowSuccesses++
+	c.mu.consecutiveFailures = 0
+	if c.mu.breaker != BreakerClosed {
+		b.sharedHealth.publish(c.mu.name, false, time.Time{})
+	}
+	c.setBreakerLocked(BreakerClosed)
+}
+
+// recordFailureLocked updates the connector's state after a failed connect.
+// The connector's mutex must be held.
+func (b *Balancer) recordFailureLocked(c *connector, err error) {
+	now := b.now()
+	c.mu.failures++
+	c.mu.windowAttempts++
+	if c.mu.categories == nil {
+		c.mu.categories = map[string]int64{}
+	}
+	c.mu.categories[b.classify(err)]++
+	c.mu.recentFailures.add(now)
+	if b.failureCache > 0 {
+		c.mu.failedUntil = now.Add(b.failureCache)
+	}
+	c.mu.lastErr = err
+	c.mu.lastErrTime = now
+	c.mu.consecutiveFailures++
+
+	if b.breakerFailures <= 0 {
+		return
+	}
+	if c.mu.breaker == BreakerHalfOpen || c.mu.consecutiveFailures >= b.breakerFailures {
+		c.setBreakerLocked(BreakerOpen)
+		c.mu.retryAt = now.Add(b.breakerCooldown)
+		b.sharedHealth.publish(c.mu.name, true, c.mu.retryAt)
+	}
+}
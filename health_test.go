@@ -0,0 +1,205 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(100, 0)
+	b := NewBalancer(WithCircuitBreaker(1, time.Minute))
+	b.now = func() time.Time { return now }
+	b.Add("err", errConnector{})
+
+	if _, err := b.Connect(context.Background()); err == nil || err == ErrNoHealthyConnectors {
+		t.Fatalf("expected connect error; got %+v", err)
+	}
+	if _, err := b.Connect(context.Background()); err != ErrNoHealthyConnectors {
+		t.Fatalf("expected %+v; got %+v", ErrNoHealthyConnectors, err)
+	}
+
+	// After the cooldown the connector is probed again and ejected when it
+	// fails.
+	now = now.Add(time.Minute)
+	if _, err := b.Connect(context.Background()); err == nil || err == ErrNoHealthyConnectors {
+		t.Fatalf("expected connect error; got %+v", err)
+	}
+	if _, err := b.Connect(context.Background()); err != ErrNoHealthyConnectors {
+		t.Fatalf("expected %+v; got %+v", ErrNoHealthyConnectors, err)
+	}
+
+	// A successful probe returns the connector to selection.
+	now = now.Add(time.Minute)
+	b.mu.connectors["err"].Connector = testConnector{}
+	for i := 0; i < 2; i++ {
+		if _, err := b.Connect(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if h := b.Describe()[0].Health; h != Healthy {
+		t.Fatalf("expected %s; got %s", Healthy, h)
+	}
+}
+
+func TestBreakerState(t *testing.T) {
+	now := time.Unix(100, 0)
+	b := NewBalancer(WithCircuitBreaker(1, time.Minute))
+	b.now = func() time.Time { return now }
+	b.Add("a", errConnector{})
+	state := func(want BreakerState, transitions BreakerTransitions) {
+		t.Helper()
+		if s, ok := b.BreakerState("a"); !ok || s != want {
+			t.Fatalf("expected %s; got %s", want, s)
+		}
+		if got := b.Describe()[0].BreakerTransitions; got != transitions {
+			t.Fatalf("expected transitions %+v; got %+v", transitions, got)
+		}
+	}
+
+	state(BreakerClosed, BreakerTransitions{})
+	b.Connect(context.Background())
+	state(BreakerOpen, BreakerTransitions{Opened: 1})
+	now = now.Add(time.Minute)
+	state(BreakerHalfOpen, BreakerTransitions{Opened: 1, HalfOpened: 1})
+	b.Connect(context.Background())
+	state(BreakerOpen, BreakerTransitions{Opened: 2, HalfOpened: 1})
+
+	now = now.Add(time.Minute)
+	b.mu.connectors["a"].Connector = testConnector{}
+	b.Connect(context.Background())
+	state(BreakerClosed, BreakerTransitions{Opened: 2, HalfOpened: 2, Closed: 1})
+
+	if _, ok := b.BreakerState("missing"); ok {
+		t.Fatal("expected a missing connector to not be found")
+	}
+}
+
+func TestSingleHalfOpenProbe(t *testing.T) {
+	now := time.Unix(100, 0)
+	var dials atomic.Int32
+	fail := atomic.Bool{}
+	fail.Store(true)
+	release := make(chan struct{})
+	b := NewBalancer(WithCircuitBreaker(1, time.Minute))
+	b.now = func() time.Time { return now }
+	b.Add("a", connectorFunc(func(context.Context) (driver.Conn, error) {
+		if fail.Load() {
+			return nil, errors.New("down")
+		}
+		dials.Add(1)
+		<-release
+		return pingConn{}, nil
+	}))
+	b.Connect(context.Background())
+	fail.Store(false)
+	now = now.Add(time.Minute)
+
+	const n = 10
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := b.Connect(context.Background())
+			errs <- err
+		}()
+	}
+	// Every Connect but the probe fails without dialing.
+	for i := 0; i < n-1; i++ {
+		if err := <-errs; err == nil {
+			t.Fatal("expected only the probe to connect")
+		}
+	}
+	close(release)
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if d := dials.Load(); d != 1 {
+		t.Fatalf("expected a single probe dial; got %d", d)
+	}
+	if s, _ := b.BreakerState("a"); s != BreakerClosed {
+		t.Fatalf("expected %s; got %s", BreakerClosed, s)
+	}
+}
+
+func TestOnAllUnhealthy(t *testing.T) {
+	now := time.Unix(100, 0)
+	var unhealthy, recovered int
+	b := NewBalancer(
+		WithCircuitBreaker(1, time.Minute),
+		WithOnAllUnhealthy(func() { unhealthy++ }),
+		WithOnRecovered(func() { recovered++ }),
+	)
+	b.now = func() time.Time { return now }
+	b.Add("a", errConnector{})
+	b.Add("b", errConnector{})
+
+	for i := 0; i < 3; i++ {
+		b.Connect(context.Background())
+	}
+	if unhealthy != 1 || recovered != 0 {
+		t.Fatalf("expected one all unhealthy call; got %d and %d recoveries", unhealthy, recovered)
+	}
+
+	// One connector recovering is enough.
+	now = now.Add(time.Minute)
+	b.mu.connectors["a"].Connector = testConnector{}
+	for i := 0; i < 3; i++ {
+		b.Connect(context.Background())
+	}
+	if unhealthy != 1 || recovered != 1 {
+		t.Fatalf("expected one recovery; got %d all unhealthy and %d recoveries", unhealthy, recovered)
+	}
+}
+
+func TestHealthScore(t *testing.T) {
+	b := NewBalancer(WithCircuitBreaker(1, time.Minute), WithStrategy(nameStrategy{}))
+	if score := b.HealthScore(); score != 0 {
+		t.Fatalf("expected 0 without connectors; got %f", score)
+	}
+	b.AddTiered("a", errConnector{}, 0, 5)
+	b.AddTiered("b", pingConnector{}, 0, 3)
+	b.AddTiered("c", pingConnector{}, 0, 2)
+	if score := b.HealthScore(); score != 1 {
+		t.Fatalf("expected 1 while every connector is healthy; got %f", score)
+	}
+
+	connectedName(t, b, context.Background())
+	if score := b.HealthScore(); score != 0.5 {
+		t.Fatalf("expected 0.5 with a ejected; got %f", score)
+	}
+}
+
+func TestHealthScoreLargeWeights(t *testing.T) {
+	b := NewBalancer()
+	b.AddTiered("a", pingConnector{}, 0, math.MaxInt)
+	b.AddTiered("b", pingConnector{}, 0, math.MaxInt)
+	if score := b.HealthScore(); score != 1 {
+		t.Fatalf("expected 1 without overflowing; got %f", score)
+	}
+}
+
+func TestSetHealth(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", pingConnector{})
+	b.Add("b", pingConnector{})
+	b.SetHealth("missing", false)
+
+	b.SetHealth("a", false)
+	for i := 0; i < 10; i++ {
+		if name := connectedName(t, b, context.Background()); name != "b" {
+			t.Fatalf("expected a to be excluded; got %s", name)
+		}
+	}
+	if h := b.Describe()[0].Health; h != Ejected {
+		t.Fatalf("expected %s; got %s", Ejected, h)
+	}
+
+	b.SetHealth("a", true)
+	if names := candidateNames(t, b); len(names) != 2 {
+		t.Fatalf("expected both connectors; got %+v", names)
+	}
+}
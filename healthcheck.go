@@ -0,0 +1,54 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+)
+
+// SetHealthConnector makes CheckHealth probe the named connector by dialing
+// probe instead of the connector itself, for example to use separate
+// credentials or a lighter weight endpoint for monitoring. Connect still uses
+// the main connector. A nil probe goes back to probing the main connector.
+func (b *Balancer) SetHealthConnector(name string, probe driver.Connector) {
+	c, ok := b.lookup(name)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mu.probe = probe
+}
+
+// CheckHealth probes each connector by connecting to it, pinging the
+// connection if it implements driver.Pinger and closing it, using the probe
+// connector set by SetHealthConnector if there is one. Connectors that fail
+// their probe are ejected from selection until a later CheckHealth succeeds,
+// and warming connectors become healthy once they've passed enough probes,
+// see WithWarmup.
+// It returns an AttemptError for each failed probe, joined with errors.Join,
+// and is meant to be called periodically.
+func (b *Balancer) CheckHealth(ctx context.Context) error {
+	var errs []error
+	for _, c := range *b.connectors.Load() {
+		c.mu.Lock()
+		name := c.mu.name
+		var probe driver.Connector = c.Connector
+		if c.mu.probe != nil {
+			probe = c.mu.probe
+		}
+		c.mu.Unlock()
+
+		err := ping(ctx, probe)
+		b.updateConnector(c, func() {
+			c.mu.probeFailed = err != nil
+			b.warmLocked(c, err == nil)
+		})
+		if err != nil {
+			errs = append(errs, AttemptError{Name: name, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
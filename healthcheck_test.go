@@ -0,0 +1,40 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSetHealthConnector(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", pingConnector{})
+	b.Add("b", pingConnector{})
+	errDown := errors.New("probe down")
+	b.SetHealthConnector("a", failConnector{errDown})
+	b.SetHealthConnector("missing", failConnector{errDown})
+	ctx := context.Background()
+
+	err := b.CheckHealth(ctx)
+	var attemptErr AttemptError
+	if !errors.As(err, &attemptErr) || attemptErr.Name != "a" || !errors.Is(err, errDown) {
+		t.Fatalf("expected the probe of a to fail; got %+v", err)
+	}
+	if names := candidateNames(t, b); len(names) != 1 || names[0] != "b" {
+		t.Fatalf("expected a to be ejected; got %+v", names)
+	}
+	for i := 0; i < 10; i++ {
+		if name := connectedName(t, b, ctx); name != "b" {
+			t.Fatalf("expected b; got %q", name)
+		}
+	}
+
+	// Going back to probing the main connector returns it to selection.
+	b.SetHealthConnector("a", nil)
+	if err := b.CheckHealth(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if names := candidateNames(t, b); len(names) != 2 {
+		t.Fatalf("expected both connectors; got %+v", names)
+	}
+}
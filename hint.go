@@ -0,0 +1,60 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+)
+
+type hintKey struct{}
+
+// WithConnectorHint returns a context that makes Connect attempt the named
+// connector first while it's healthy, like WithPreferred does for every call.
+// The other connectors are still used for failover. A hint for a connector
+// that isn't in the balancer is ignored unless WithStrictHints is set.
+func WithConnectorHint(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, hintKey{}, name)
+}
+
+// hintFromContext returns the connector hint set by WithConnectorHint.
+func hintFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(hintKey{}).(string)
+	return name, ok
+}
+
+// WithStrictHints makes Connect fail with ErrConnectorNotFound when the
+// context hints at a connector that isn't in the balancer, see
+// WithConnectorHint. This catches code referencing a backend that has been
+// removed.
+func WithStrictHints(strict bool) Option {
+	return func(b *Balancer) {
+		b.strictHints = strict
+	}
+}
+
+// checkHint returns ErrConnectorNotFound if strict hints are enabled and the
+// hinted connector doesn't exist.
+func (b *Balancer) checkHint(name string) error {
+	if !b.strictHints {
+		return nil
+	}
+	if _, ok := b.lookup(name); !ok {
+		return fmt.Errorf("%w: %q", ErrConnectorNotFound, name)
+	}
+	return nil
+}
+
+// hintedConnector is a driver.Connector that connects through the balancer
+// with a connector hint.
+type hintedConnector struct {
+	b    *Balancer
+	name string
+}
+
+func (c hintedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.b.Connect(WithConnectorHint(ctx, c.name))
+}
+
+func (c hintedConnector) Driver() driver.Driver {
+	return c.b
+}
@@ -0,0 +1,80 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestConnectorHint(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", pingConnector{})
+	b.Add("b", pingConnector{})
+	b.Add("c", pingConnector{})
+
+	ctx := WithConnectorHint(context.Background(), "b")
+	for i := 0; i < 20; i++ {
+		if name := connectedName(t, b, ctx); name != "b" {
+			t.Fatalf("expected the hinted connector; got %s", name)
+		}
+	}
+
+	// Missing connectors are ignored by default.
+	connectedName(t, b, WithConnectorHint(context.Background(), "missing"))
+}
+
+func TestStrictHints(t *testing.T) {
+	b := NewBalancer(WithStrictHints(true))
+	b.Add("a", pingConnector{})
+
+	_, err := b.Connect(WithConnectorHint(context.Background(), "missing"))
+	if !errors.Is(err, ErrConnectorNotFound) {
+		t.Fatalf("expected %+v; got %+v", ErrConnectorNotFound, err)
+	}
+	if want := `lbsql: connector not found: "missing"`; err.Error() != want {
+		t.Fatalf("expected %q; got %q", want, err.Error())
+	}
+
+	if name := connectedName(t, b, WithConnectorHint(context.Background(), "a")); name != "a" {
+		t.Fatalf("expected a; got %s", name)
+	}
+}
+
+func TestOpenHint(t *testing.T) {
+	b := NewBalancer()
+	b.Add("replica1", pingConnector{})
+	b.Add("replica2", pingConnector{})
+	b.Add("replica3", pingConnector{})
+
+	connector, err := b.OpenConnector("replica2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if connector.Driver() != b {
+		t.Fatal("expected the balancer as the driver")
+	}
+	for i := 0; i < 20; i++ {
+		c, err := b.Open("replica2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name := c.(*conn).c.name(); name != "replica2" {
+			t.Fatalf("expected Open to prefer replica2; got %s", name)
+		}
+		c.Close()
+
+		c, err = connector.Connect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name := c.(*conn).c.name(); name != "replica2" {
+			t.Fatalf("expected the connector to prefer replica2; got %s", name)
+		}
+		c.Close()
+	}
+
+	if connector, _ := b.OpenConnector(""); connector != driver.Connector(b) {
+		t.Fatal("expected an empty name to return the balancer")
+	}
+}
@@ -0,0 +1,59 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+)
+
+// ErrHookRejected is returned by Connect when the connect hook returns none of
+// the candidates, see WithConnectHook.
+var ErrHookRejected = errors.New("lbsql: connect hook rejected every connector")
+
+// WithConnectHook sets a hook that is called with the ordered candidates at
+// the start of each Connect pass. The hook returns the connectors to attempt
+// in the order to attempt them, which may drop or reorder the candidates.
+// Connectors that weren't passed to the hook are ignored, and if the hook
+// returns none Connect fails with ErrHookRejected. An error from the hook is
+// returned by Connect without attempting any connectors. Neither is retried
+// by WithSetRetries.
+func WithConnectHook(hook func(ctx context.Context, candidates []NamedConnector) ([]NamedConnector, error)) Option {
+	return func(b *Balancer) {
+		b.connectHook = hook
+	}
+}
+
+// hookConnector is a candidate as passed to the connect hook.
+type hookConnector struct {
+	driver.Connector
+
+	info ConnectorInfo
+}
+
+func (c hookConnector) Name() string {
+	return c.info.Name
+}
+
+// runConnectHook filters and reorders the candidates with the connect hook.
+func (b *Balancer) runConnectHook(ctx context.Context, candidates []ConnectorInfo) ([]ConnectorInfo, error) {
+	named := make([]NamedConnector, len(candidates))
+	for i, c := range candidates {
+		named[i] = hookConnector{Connector: c.c.Connector, info: c}
+	}
+
+	named, err := b.connectHook(ctx, named)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates = candidates[:0]
+	for _, c := range named {
+		if hc, ok := c.(hookConnector); ok {
+			candidates = append(candidates, hc.info)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrHookRejected
+	}
+	return candidates, nil
+}
@@ -0,0 +1,78 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestConnectHook(t *testing.T) {
+	var seen []string
+	b := NewBalancer(
+		WithStrategy(nameStrategy{}),
+		WithConnectHook(func(ctx context.Context, candidates []NamedConnector) ([]NamedConnector, error) {
+			seen = seen[:0]
+			for _, c := range candidates {
+				seen = append(seen, c.Name())
+			}
+			return candidates[len(candidates)-1:], nil
+		}),
+	)
+	b.Add("a", pingConnector{})
+	b.Add("b", pingConnector{})
+	b.Add("c", failConnector{err: errors.New("c failed")})
+
+	_, err := b.Connect(context.Background())
+	var connErr *ConnectError
+	if !errors.As(err, &connErr) || len(connErr.Errors) != 1 || connErr.Errors[0].Name != "c" {
+		t.Fatalf("expected only c to be attempted; got %+v", err)
+	}
+	if len(seen) != 3 || seen[0] != "a" || seen[2] != "c" {
+		t.Fatalf("expected the hook to see the ordered candidates; got %+v", seen)
+	}
+	for _, info := range b.Describe() {
+		if info.Name != "c" && info.Connects != 0 {
+			t.Fatalf("expected %s to not be attempted; got %+v", info.Name, info)
+		}
+	}
+}
+
+func TestConnectHookError(t *testing.T) {
+	hookErr := errors.New("no route")
+	calls := 0
+	b := NewBalancer(WithSetRetries(2), WithConnectHook(func(context.Context, []NamedConnector) ([]NamedConnector, error) {
+		calls++
+		return nil, hookErr
+	}))
+	b.Add("a", pingConnector{})
+
+	if _, err := b.Connect(context.Background()); err != hookErr {
+		t.Fatalf("expected %+v; got %+v", hookErr, err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected the hook error to abort without retrying; got %d calls", calls)
+	}
+	if info := b.Describe()[0]; info.Connects != 0 || info.Failures != 0 {
+		t.Fatalf("expected a to not be attempted; got %+v", info)
+	}
+}
+
+func TestConnectHookRejectsAll(t *testing.T) {
+	calls := 0
+	b := NewBalancer(
+		WithSetRetries(3),
+		WithSetRetryBackoff(0),
+		WithConnectHook(func(ctx context.Context, candidates []NamedConnector) ([]NamedConnector, error) {
+			calls++
+			return nil, nil
+		}),
+	)
+	b.Add("a", pingConnector{})
+
+	if _, err := b.Connect(context.Background()); err != ErrHookRejected {
+		t.Fatalf("expected %+v; got %+v", ErrHookRejected, err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected the veto to not be retried; got %d hook calls", calls)
+	}
+}
@@ -0,0 +1,48 @@
+package lbsql
+
+import (
+	"context"
+	"time"
+)
+
+// WithIdleProbe pings connections that have been idle for longer than maxIdle
+// when database/sql checks whether they can be reused. If the ping fails the
+// connection is reported as invalid so database/sql discards it and dials a
+// new one, instead of handing out a connection the backend silently dropped.
+// The ping is bounded by the dial timeout, see WithDialTimeout.
+func WithIdleProbe(maxIdle time.Duration) Option {
+	return func(b *Balancer) {
+		b.maxIdle = maxIdle
+	}
+}
+
+// touch marks the connection as used.
+func (c *conn) touch() {
+	if c.b != nil && c.b.maxIdle > 0 {
+		c.lastUsed.Store(c.b.now().UnixNano())
+	}
+}
+
+// probeIdle pings the connection if it's been idle for longer than the idle
+// probe allows, reporting whether it's still usable.
+func (c *conn) probeIdle() bool {
+	if c.b == nil || c.b.maxIdle <= 0 {
+		return true
+	}
+	now := c.b.now()
+	if now.Sub(time.Unix(0, c.lastUsed.Load())) <= c.b.maxIdle {
+		return true
+	}
+
+	ctx := context.Background()
+	if c.b.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.b.dialTimeout)
+		defer cancel()
+	}
+	if err := c.Ping(ctx); err != nil {
+		return false
+	}
+	c.lastUsed.Store(now.UnixNano())
+	return true
+}
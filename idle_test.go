@@ -0,0 +1,40 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIdleProbe(t *testing.T) {
+	b := NewBalancer(WithIdleProbe(time.Minute))
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	b.Add("up", pingConnector{})
+	b.Add("down", pingConnector{err: errors.New("connection reset")})
+
+	conns := map[string]driver.Validator{}
+	for len(conns) < 2 {
+		c, err := b.Connect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns[c.(ConnNamer).ConnectorName()] = c.(driver.Validator)
+	}
+
+	for name, c := range conns {
+		if !c.IsValid() {
+			t.Fatalf("expected recently used %s to be valid", name)
+		}
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !conns["up"].IsValid() {
+		t.Fatal("expected idle conn with a working ping to be valid")
+	}
+	if conns["down"].IsValid() {
+		t.Fatal("expected idle conn with a failing ping to be invalid")
+	}
+}
@@ -0,0 +1,49 @@
+package lbsql
+
+import "context"
+
+type labelsKey struct{}
+
+// WithConnectLabels returns a context that attributes the connects made with
+// it to the given labels, such as tenant=acme, in ConnectorInfo.LabelConnects.
+// Only the label keys allowed by WithAllowedLabelKeys are counted, the rest are
+// dropped.
+func WithConnectLabels(ctx context.Context, labels map[string]string) context.Context {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return context.WithValue(ctx, labelsKey{}, copied)
+}
+
+// labelsFromContext returns the labels set by WithConnectLabels.
+func labelsFromContext(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(labelsKey{}).(map[string]string)
+	return labels
+}
+
+// WithAllowedLabelKeys sets the label keys counted for WithConnectLabels. No
+// labels are counted by default, which keeps the number of counters bounded
+// by the keys chosen here and the values used with them.
+func WithAllowedLabelKeys(keys ...string) Option {
+	return func(b *Balancer) {
+		b.labelKeys = make(map[string]bool, len(keys))
+		for _, k := range keys {
+			b.labelKeys[k] = true
+		}
+	}
+}
+
+// recordLabelsLocked counts a successful connect for each allowed label. The
+// connector's mutex must be held.
+func (b *Balancer) recordLabelsLocked(c *connector, labels map[string]string) {
+	for k, v := range labels {
+		if !b.labelKeys[k] {
+			continue
+		}
+		if c.mu.labelConnects == nil {
+			c.mu.labelConnects = map[string]int64{}
+		}
+		c.mu.labelConnects[k+"="+v]++
+	}
+}
@@ -0,0 +1,26 @@
+package lbsql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestConnectLabels(t *testing.T) {
+	b := NewBalancer(WithAllowedLabelKeys("tenant"))
+	b.Add("a", pingConnector{})
+
+	labels := map[string]string{"tenant": "acme", "request": "123"}
+	ctx := WithConnectLabels(context.Background(), labels)
+	for i := 0; i < 2; i++ {
+		connectedName(t, b, ctx)
+	}
+	connectedName(t, b, WithConnectLabels(context.Background(), map[string]string{"tenant": "initech"}))
+	connectedName(t, b, context.Background())
+
+	info := b.Describe()[0]
+	want := map[string]int64{"tenant=acme": 2, "tenant=initech": 1}
+	if !reflect.DeepEqual(info.LabelConnects, want) {
+		t.Fatalf("expected %+v; got %+v", want, info.LabelConnects)
+	}
+}
@@ -0,0 +1,76 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// AddLazy adds a connector that is built by factory the first time it's
+// selected. The built connector is cached, while a factory error counts as a
+// failed connect and the factory is called again the next time the connector
+// is selected.
+func (b *Balancer) AddLazy(name string, factory func() (driver.Connector, error)) {
+	b.Add(name, &lazyConnector{factory: factory})
+}
+
+// lazyConnector is a driver.Connector that builds the underlying connector on
+// first use.
+type lazyConnector struct {
+	factory func() (driver.Connector, error)
+
+	mu struct {
+		sync.Mutex
+
+		connector driver.Connector
+	}
+}
+
+// get returns the underlying connector, building it if needed.
+func (l *lazyConnector) get() (driver.Connector, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.mu.connector == nil {
+		c, err := l.factory()
+		if err != nil {
+			return nil, fmt.Errorf("lbsql: building connector: %w", err)
+		}
+		l.mu.connector = c
+	}
+	return l.mu.connector, nil
+}
+
+// Connect builds the underlying connector if needed and connects to it.
+func (l *lazyConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c, err := l.get()
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(ctx)
+}
+
+// Driver returns the underlying connector's driver, or nil if it can't be
+// built.
+func (l *lazyConnector) Driver() driver.Driver {
+	c, err := l.get()
+	if err != nil {
+		return nil
+	}
+	return c.Driver()
+}
+
+// Close closes the underlying connector if it has been built and implements
+// io.Closer. It doesn't build the connector.
+func (l *lazyConnector) Close() error {
+	l.mu.Lock()
+	c := l.mu.connector
+	l.mu.Unlock()
+
+	if closer, ok := c.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestAddLazy(t *testing.T) {
+	b := NewBalancer(WithStrategy(nameStrategy{}))
+
+	var calls int
+	b.AddLazy("a", func() (driver.Connector, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("not ready")
+		}
+		return pingConnector{}, nil
+	})
+	b.Add("b", pingConnector{})
+	if calls != 0 {
+		t.Fatalf("expected factory to not be called before Connect")
+	}
+
+	// The factory error fails over to b.
+	if _, err := b.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if info := b.Describe()[0]; info.Failures != 1 || info.Connects != 0 {
+		t.Fatalf("expected factory error to count as a failure; got %+v", info)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := b.Connect(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("expected factory to be called twice; got %d", calls)
+	}
+	if info := b.Describe()[0]; info.Connects != 3 {
+		t.Fatalf("expected lazy connector to be used once built; got %+v", info)
+	}
+}
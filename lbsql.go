@@ -4,48 +4,757 @@ import (
 	"context"
 	"database/sql/driver"
 	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // ErrNoConnectors is returned when there are no connectors added to the
 // balancer.
 var ErrNoConnectors = errors.New("lbsql: no available connectors")
 
+// ErrNilConn is the error recorded when a connector returns neither a
+// connection nor an error. Connect treats it like any other failed connect.
+var ErrNilConn = errors.New("lbsql: connector returned a nil connection")
+
+// ErrConnectorNotFound is returned when a connector with the given name isn't
+// in the balancer.
+var ErrConnectorNotFound = errors.New("lbsql: connector not found")
+
+// ErrNameExists is returned when a connector with the given name is already in
+// the balancer.
+var ErrNameExists = errors.New("lbsql: connector name already exists")
+
 var _ driver.Driver = &Balancer{}
 var _ driver.Connector = &Balancer{}
 var _ driver.DriverContext = &Balancer{}
 
-// Balancer is a driver.Connector that randomly picks between the connectors
-// that have been added to it when establishing connections.
+// latencyDecay is the weight given to a new sample in the connect latency
+// moving average.
+const latencyDecay = 0.2
+
+// Balancer is a driver.Connector that picks between the connectors that have
+// been added to it when establishing connections. By default connectors are
+// picked at random, see WithStrategy for other options.
 type Balancer struct {
+	strategy       Strategy
+	topK           int
+	stableShuffle  bool
+	firstAvailable bool
+	preferred      string
+	strictHints    bool
+	closeOnReset   bool
+	closeOnReplace bool
+	atomicBatches  bool
+	autoWeight     bool
+	rejectFrozen   bool
+	rejectDups     bool
+	connectNAll    bool
+	rand           randSource
+	now            func() time.Time
+	classify       func(error) string
+
+	rebalanceThreshold float64
+	exploration        float64
+	labelKeys          map[string]bool
+	sampleSize         int
+	adaptiveTimeout    struct {
+		multiplier float64
+		min, max   time.Duration
+	}
+	warmupProbes       int
+	loadFactor         float64
+	predictiveDeadline bool
+	failoverWithinTier bool
+	failFastAtMaxTotal bool
+	enforceTimeouts    bool
+
+	setRetries        int
+	setRetryBackoff   time.Duration
+	maxReportedErrors int
+
+	dialTimeout  time.Duration
+	totalTimeout time.Duration
+	connectSLO   time.Duration
+	maxIdle      time.Duration
+	failureCache time.Duration
+	errorWindow  time.Duration
+
+	validator      func(context.Context, driver.Conn) error
+	requiredIfaces []reflect.Type
+	maxDials       map[string]int
+	faultInjector  func(name string) error
+	sharedHealth   *SharedHealth
+	tracer         func(context.Context, ConnectTrace)
+	traceSampler   func() bool
+	connectHook    func(context.Context, []NamedConnector) ([]NamedConnector, error)
+
+	outliers *outlierDetector
+
+	breakerFailures int
+	breakerCooldown time.Duration
+
+	onAllUnhealthy func()
+	onRecovered    func()
+	// allUnhealthy is whether the all unhealthy hook has been called without
+	// the balancer recovering since.
+	allUnhealthy atomic.Bool
+	// draining is set by Drain.
+	draining atomic.Bool
+	// chain is the middleware wrapped around Connect, see Use.
+	chain atomic.Pointer[ConnectFunc]
+	// ordered is set once a connector is added with a tier or role, which
+	// rules out the fast path in Connect.
+	ordered atomic.Bool
+
+	// connectors is an immutable snapshot of the connectors so Connect can
+	// read them without taking the mutex. It's replaced whenever the set of
+	// connectors changes.
+	connectors atomic.Pointer[[]*connector]
+
 	mu struct {
 		sync.Mutex
 
-		connectors map[string]driver.Connector
+		connectors map[string]*connector
+		// autoName is the next index used by AddAuto.
+		autoName int
+
+		subscribers map[int]func(RebalanceEvent)
+		nextSub     int
+
+		middleware []ConnectMiddleware
+
+		// frozen is set by Freeze, and thawed is signaled when Unfreeze
+		// clears it.
+		frozen bool
+		thawed *sync.Cond
+	}
+
+	// stats are the balancer wide counters. They have their own mutex so
+	// updating them doesn't contend with changes to the connectors.
+	stats struct {
+		sync.Mutex
+
+		connects      int64
+		failures      int64
+		connectTime   time.Duration
+		deadlineUsage histogram
+		sloViolations int64
+	}
+
+	// opts are the options the balancer was created with, see Clone.
+	opts []Option
+
+	// total counts the connections open across the balancer for
+	// SetMaxTotalOpen, and released is closed when one is released.
+	total struct {
+		sync.Mutex
+
+		open, max int
+		released  chan struct{}
+	}
+
+	// memo is the connector each memo key last connected to, see
+	// WithConnectMemo.
+	memo struct {
+		sync.Mutex
+
+		window  time.Duration
+		entries map[string]memoEntry
+	}
+}
+
+// connector is a driver.Connector that has been added to the balancer along
+// with the state used to select it.
+type connector struct {
+	driver.Connector
+
+	// generation is incremented to invalidate all open connections, see
+	// InvalidateConnections.
+	generation atomic.Uint64
+	// dials holds a slot for each dial in progress if concurrent dials are
+	// limited, see WithMaxConcurrentDials.
+	dials chan struct{}
+
+	mu struct {
+		sync.Mutex
+
+		name        string
+		weight      int
+		ramp        *weightRamp
+		tier        int
+		role        Role
+		latency     time.Duration
+		dialTimeout time.Duration
+		retryPolicy RetryPolicy
+		initStmts   []string
+		metricTag   string
+		tags        map[string]string
+
+		open        int
+		dialing     int
+		maxOpen     int
+		connects    int64
+		failures    int64
+		categories  map[string]int64
+		lastErr     error
+		lastErrTime time.Time
+		lastSuccess time.Time
+
+		sloViolations  int64
+		labelConnects  map[string]int64
+		recentFailures windowCounter
+		recentConnects windowCounter
+		holdTime       histogram
+		queueWait      histogram
+
+		breaker             BreakerState
+		breakerTransitions  BreakerTransitions
+		consecutiveFailures int
+		retryAt             time.Time
+		// probing is set while a dial is probing a half-open breaker.
+		probing bool
+		// sharedGen is the generation of the SharedHealth verdicts that
+		// were last checked.
+		sharedGen uint64
+
+		// windowAttempts and windowSuccesses count connects since outlier
+		// detection last ran.
+		windowAttempts  int
+		windowSuccesses int
+		outlier         bool
+		ejectedUntil    time.Time
+
+		avoidUntil  time.Time
+		failedUntil time.Time
+
+		// probe is dialed by CheckHealth instead of the connector if set,
+		// and probeFailed is whether the last health check failed.
+		probe       driver.Connector
+		probeFailed bool
+		// markedDown is set by SetHealth.
+		markedDown bool
+
+		// warming is set until the connector has passed warmupPassed of
+		// the probes required by WithWarmup.
+		warming      bool
+		warmupPassed int
+
+		// reportedHealth and reportedWeight are the values from the last
+		// RebalanceEvent.
+		reportedHealth Health
+		reportedWeight int
+	}
+}
+
+// infoLocked returns a snapshot of the connector at the given time. The
+// connector's mutex must be held.
+func (c *connector) infoLocked(now time.Time) ConnectorInfo {
+	info := ConnectorInfo{
+		Name:           c.mu.name,
+		Weight:         c.weightLocked(now),
+		Tier:           c.mu.tier,
+		Role:           c.mu.role,
+		MetricTag:      c.mu.metricTag,
+		Tags:           c.mu.tags,
+		Latency:        c.mu.latency,
+		Open:           c.mu.open,
+		MaxOpen:        c.mu.maxOpen,
+		Connects:       c.mu.connects,
+		Failures:       c.mu.failures,
+		Categories:     make(map[string]int64, len(c.mu.categories)),
+		LastError:      c.mu.lastErr,
+		LastErrTime:    c.mu.lastErrTime,
+		LastSuccess:    c.mu.lastSuccess,
+		SLOViolations:  c.mu.sloViolations,
+		RecentFailures: c.mu.recentFailures.sum(now),
+		RecentConnects: c.mu.recentConnects.sum(now),
+		HoldTime:       c.mu.holdTime.snapshot(),
+		QueueWait:      c.mu.queueWait.snapshot(),
+		Health:         c.healthLocked(),
+		Avoided:        now.Before(c.mu.avoidUntil),
+		RecentlyFailed: now.Before(c.mu.failedUntil),
+		c:              c,
+	}
+	info.Breaker = c.mu.breaker
+	info.BreakerTransitions = c.mu.breakerTransitions
+	for category, n := range c.mu.categories {
+		info.Categories[category] = n
+	}
+	if c.mu.labelConnects != nil {
+		info.LabelConnects = make(map[string]int64, len(c.mu.labelConnects))
+		for label, n := range c.mu.labelConnects {
+			info.LabelConnects[label] = n
+		}
+	}
+	if info.Health == Healthy {
+		info.EffectiveWeight = info.Weight
+	}
+	return info
+}
+
+// info returns a snapshot of the connector at the given time.
+func (c *connector) info(now time.Time) ConnectorInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.infoLocked(now)
+}
+
+// name returns the current name of the connector.
+func (c *connector) name() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.mu.name
+}
+
+// ConnectorInfo is a snapshot of the state of a connector in the balancer.
+type ConnectorInfo struct {
+	// Name is the name the connector was added with.
+	Name string
+	// Weight is the weight of the connector, see SetWeight.
+	Weight int
+	// Tier is the tier of the connector, see AddTiered.
+	Tier int
+	// Role is whether the connector accepts writes, see AddRole.
+	Role Role
+	// MetricTag is the tag the connector's metrics are aggregated under, see
+	// AddWithMetricTag.
+	MetricTag string
+	// Tags are the connector's tags, see AddTagged. They must not be
+	// modified.
+	Tags map[string]string
+	// Latency is a moving average of the time taken to successfully connect.
+	// It is zero if the connector hasn't connected yet.
+	Latency time.Duration
+
+	// Open is the number of connections from the connector that haven't been
+	// closed.
+	Open int
+	// MaxOpen is the limit on open connections from the connector, or zero if
+	// there is no limit, see SetMaxOpen.
+	MaxOpen int
+	// Connects is the total number of successful connects.
+	Connects int64
+	// Failures is the total number of failed connects.
+	Failures int64
+	// Categories is the number of failed connects in each error category, see
+	// WithErrorClassifier.
+	Categories map[string]int64
+	// LastError is the error from the most recent failed connect.
+	LastError error
+	// LastErrTime is when the most recent failed connect happened.
+	LastErrTime time.Time
+	// LastSuccess is when the most recent successful connect happened.
+	LastSuccess time.Time
+	// SLOViolations is the number of connects to the connector, successful or
+	// not, that took longer than the SLO set by WithConnectSLO.
+	SLOViolations int64
+	// Health is the health state of the connector.
+	Health Health
+	// Avoided is whether the connector is being avoided, see
+	// TemporarilyAvoid.
+	Avoided bool
+	// RecentlyFailed is whether the connector failed to connect within the
+	// failure cache's TTL, see WithFailureCache.
+	RecentlyFailed bool
+	// EffectiveWeight is the weight the connector currently receives traffic
+	// with. It is zero for connectors that aren't healthy.
+	EffectiveWeight int
+	// RecentFailures is the number of failed connects within the error
+	// window, see WithErrorWindow.
+	RecentFailures int64
+	// RecentConnects is the number of successful connects within the error
+	// window.
+	RecentConnects int64
+	// HoldTime is how long connections from the connector were held open,
+	// from Connect returning them to them being closed, in seconds.
+	HoldTime Histogram
+	// Breaker is the state of the connector's circuit breaker and
+	// BreakerTransitions counts its changes of state.
+	Breaker            BreakerState
+	BreakerTransitions BreakerTransitions
+	// QueueWait is how long connects waited for a dial slot before dialing,
+	// in seconds, see WithMaxConcurrentDials. It's separate from Latency,
+	// which only covers the dial.
+	QueueWait Histogram
+	// LabelConnects is the number of successful connects with each label,
+	// keyed by key=value, see WithConnectLabels.
+	LabelConnects map[string]int64
+
+	c *connector
+}
+
+// Option configures a Balancer.
+type Option func(*Balancer)
+
+// WithStrategy sets the strategy used to pick connectors. The default is
+// RandomStrategy.
+func WithStrategy(s Strategy) Option {
+	return func(b *Balancer) {
+		b.strategy = s
+	}
+}
+
+// WithTopK restricts the first pick to a random connector among the k best
+// connectors as ranked by the strategy. It only applies to strategies that
+// implement Ranker, such as LatencyStrategy and WeightedStrategy. The remaining
+// connectors are used for failover in rank order.
+func WithTopK(k int) Option {
+	return func(b *Balancer) {
+		b.topK = k
 	}
 }
 
 // NewBalancer returns a Balancer.
-func NewBalancer() *Balancer {
-	b := &Balancer{}
-	b.mu.connectors = map[string]driver.Connector{}
+func NewBalancer(opts ...Option) *Balancer {
+	b := &Balancer{
+		strategy: RandomStrategy{},
+		now:      time.Now,
+		classify: DefaultErrorClassifier,
+		rand:     mathRand{},
+
+		rebalanceThreshold: defaultRebalanceThreshold,
+		setRetryBackoff:    defaultSetRetryBackoff,
+		errorWindow:        defaultErrorWindow,
+	}
+	b.mu.connectors = map[string]*connector{}
+	b.mu.thawed = sync.NewCond(&b.mu)
+	b.connectors.Store(&[]*connector{})
+	b.stats.deadlineUsage = newHistogram(deadlineUsageBounds)
+	b.opts = append([]Option(nil), opts...)
+	for _, opt := range opts {
+		opt(b)
+	}
 	return b
 }
 
-// Add adds a driver.Connector to the balancer.
+// Add adds a driver.Connector to the balancer. Adding a connector under a name
+// that's already in use replaces the old connector and starts its state, such
+// as weights and health, over from the defaults. The old connector is only
+// closed if WithCloseOnReplace is set.
+//
+// Add does nothing, without reporting it, if the connector is rejected by
+// WithRejectWhileFrozen or WithRejectDuplicateConnectors. Use TryAdd to get
+// the error instead.
 func (b *Balancer) Add(name string, c driver.Connector) {
+	b.TryAdd(name, c)
+}
+
+// TryAdd is like Add but returns ErrFrozen or ErrDuplicateConnector if the
+// connector is rejected by WithRejectWhileFrozen or
+// WithRejectDuplicateConnectors.
+func (b *Balancer) TryAdd(name string, c driver.Connector) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	b.mu.connectors[name] = c
+	if err := b.checkAddLocked(name, c); err != nil {
+		return err
+	}
+
+	b.addLocked(name, c)
+	return nil
+}
+
+// NamedConnector is a driver.Connector that knows its own name.
+type NamedConnector interface {
+	driver.Connector
+
+	// Name returns the name of the connector.
+	Name() string
 }
 
-// Remove removes a connector from the balancer.
+// AddNamed adds a NamedConnector to the balancer under the name it reports.
+// Like Add it does nothing if the connector is rejected.
+func (b *Balancer) AddNamed(c NamedConnector) {
+	b.Add(c.Name(), c)
+}
+
+// AddAuto adds a driver.Connector to the balancer under a generated name of
+// the form conn-N and returns the name. Generated names never collide with
+// connectors already in the balancer.
+func (b *Balancer) AddAuto(c driver.Connector) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.checkAddLocked("", c) != nil {
+		return ""
+	}
+
+	for {
+		name := fmt.Sprintf("conn-%d", b.mu.autoName)
+		b.mu.autoName++
+		if _, ok := b.mu.connectors[name]; !ok {
+			b.addLocked(name, c)
+			return name
+		}
+	}
+}
+
+// checkAddLocked returns an error if the connector can't be added under the
+// name because the balancer is frozen or it's a duplicate. The balancer's
+// mutex must be held.
+func (b *Balancer) checkAddLocked(name string, c driver.Connector) error {
+	if err := b.waitUnfrozenLocked(); err != nil {
+		return err
+	}
+	return b.duplicateLocked(name, c)
+}
+
+// addLocked adds a connector. The balancer's mutex must be held.
+func (b *Balancer) addLocked(name string, c driver.Connector) *connector {
+	conn := b.insertLocked(name, c)
+	b.publishLocked()
+	return conn
+}
+
+// insertLocked adds a connector without publishing it to Connect. The
+// balancer's mutex must be held.
+func (b *Balancer) insertLocked(name string, c driver.Connector) *connector {
+	if old, ok := b.mu.connectors[name]; ok && b.closeOnReplace && !sameConnector(old.Connector, c) {
+		if closer, ok := old.Connector.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+
+	conn := &connector{Connector: c}
+	if k := b.maxDials[name]; k > 0 {
+		conn.dials = make(chan struct{}, k)
+	}
+	conn.mu.name = name
+	conn.mu.weight = 1
+	conn.mu.reportedWeight = 1
+	conn.mu.recentFailures.width = b.errorWindow / windowBuckets
+	conn.mu.recentConnects.width = b.errorWindow / windowBuckets
+	conn.mu.holdTime = newHistogram(holdTimeBounds)
+	conn.mu.queueWait = newHistogram(queueWaitBounds)
+	if b.warmupProbes > 0 {
+		conn.mu.warming = true
+		conn.mu.reportedHealth = Warming
+		conn.mu.reportedWeight = 0
+	}
+	b.mu.connectors[name] = conn
+	return conn
+}
+
+// sameConnector reports whether a and b are the same connector, without
+// panicking on connectors that can't be compared.
+func sameConnector(a, b driver.Connector) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
+
+// Remove removes a connector from the balancer. All of the state kept for it,
+// such as its stats, health and last error, is discarded with it, so adding a
+// connector under the same name later starts from scratch. Connections already
+// open from it keep working.
+//
+// Remove does nothing, without reporting it, while the balancer is frozen with
+// WithRejectWhileFrozen. Use TryRemove to get the error instead.
 func (b *Balancer) Remove(name string) {
+	b.TryRemove(name)
+}
+
+// TryRemove is like Remove but returns ErrFrozen if the balancer is frozen
+// with WithRejectWhileFrozen, and ErrConnectorNotFound if there is no
+// connector with the name.
+func (b *Balancer) TryRemove(name string) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err := b.waitUnfrozenLocked(); err != nil {
+		return err
+	}
+	if _, ok := b.mu.connectors[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrConnectorNotFound, name)
+	}
+
 	delete(b.mu.connectors, name)
+	b.publishLocked()
+	return nil
+}
+
+// RemoveFunc removes every connector for which pred returns true and returns
+// their names in sorted order. The connectors are removed atomically, so
+// Connect sees either all or none of them. pred is called without holding the
+// balancer's lock, so it can call back into the balancer, and connectors that
+// are replaced before they're removed are kept.
+func (b *Balancer) RemoveFunc(pred func(name string, info ConnectorInfo) bool) []string {
+	b.mu.Lock()
+	connectors := make(map[string]*connector, len(b.mu.connectors))
+	for name, c := range b.mu.connectors {
+		connectors[name] = c
+	}
+	b.mu.Unlock()
+
+	now := b.now()
+	for name, c := range connectors {
+		if !pred(name, c.info(now)) {
+			delete(connectors, name)
+		}
+	}
+	if len(connectors) == 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.waitUnfrozenLocked() != nil {
+		return nil
+	}
+
+	var removed []string
+	for name, c := range connectors {
+		if b.mu.connectors[name] == c {
+			delete(b.mu.connectors, name)
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) > 0 {
+		b.publishLocked()
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// Count returns the number of connectors in the balancer.
+func (b *Balancer) Count() int {
+	return len(*b.connectors.Load())
+}
+
+// Reset removes every connector and clears the balancer's stats, returning it
+// to the state it was in when created. Options and subscribers are kept. With
+// WithCloseOnReset the removed connectors that implement io.Closer are closed.
+func (b *Balancer) Reset() error {
+	b.mu.Lock()
+	if err := b.waitUnfrozenLocked(); err != nil {
+		b.mu.Unlock()
+		return err
+	}
+	removed := b.mu.connectors
+	b.mu.connectors = map[string]*connector{}
+	b.mu.autoName = 0
+	b.publishLocked()
+	b.mu.Unlock()
+
+	b.stats.Lock()
+	b.stats.connects = 0
+	b.stats.failures = 0
+	b.stats.connectTime = 0
+	b.stats.deadlineUsage = newHistogram(deadlineUsageBounds)
+	b.stats.sloViolations = 0
+	b.stats.Unlock()
+
+	if !b.closeOnReset {
+		return nil
+	}
+	var errs []error
+	for _, c := range removed {
+		if closer, ok := c.Connector.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithCloseOnReset makes Reset close the connectors that implement io.Closer.
+func WithCloseOnReset(close bool) Option {
+	return func(b *Balancer) {
+		b.closeOnReset = close
+	}
+}
+
+// WithCloseOnReplace makes adding a connector under a name that's already in
+// use close the connector it replaces if it implements io.Closer. Re-adding
+// the same connector doesn't close it.
+func WithCloseOnReplace(close bool) Option {
+	return func(b *Balancer) {
+		b.closeOnReplace = close
+	}
+}
+
+// Rename changes the name of a connector, keeping its weight, counters and
+// health. It returns ErrConnectorNotFound if there is no connector named old
+// and ErrNameExists if there is already a connector named new.
+func (b *Balancer) Rename(old, new string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.waitUnfrozenLocked(); err != nil {
+		return err
+	}
+
+	c, ok := b.mu.connectors[old]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrConnectorNotFound, old)
+	}
+	if _, ok := b.mu.connectors[new]; ok {
+		return fmt.Errorf("%w: %q", ErrNameExists, new)
+	}
+
+	c.mu.Lock()
+	c.mu.name = new
+	c.mu.Unlock()
+
+	delete(b.mu.connectors, old)
+	b.mu.connectors[new] = c
+	b.publishLocked()
+	return nil
+}
+
+// publishLocked replaces the snapshot of connectors read by Connect. It must be
+// called after every change to the set of connectors. The balancer's mutex
+// must be held.
+func (b *Balancer) publishLocked() {
+	connectors := make([]*connector, 0, len(b.mu.connectors))
+	for _, c := range b.mu.connectors {
+		connectors = append(connectors, c)
+	}
+	if b.stableShuffle {
+		b.rand.Shuffle(len(connectors), func(i, j int) {
+			connectors[i], connectors[j] = connectors[j], connectors[i]
+		})
+	}
+	b.connectors.Store(&connectors)
+}
+
+// lookup returns the connector with the given name.
+func (b *Balancer) lookup(name string) (*connector, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.mu.connectors[name]
+	return c, ok
+}
+
+// SetWeight sets the weight of a connector. Connectors have a weight of 1 when
+// added. Weights are used by WeightedStrategy, negative weights are treated as
+// zero.
+func (b *Balancer) SetWeight(name string, weight int) {
+	c, ok := b.lookup(name)
+	if !ok {
+		return
+	}
+	if weight < 0 {
+		weight = 0
+	}
+
+	b.updateConnector(c, func() {
+		c.mu.weight = weight
+		c.mu.ramp = nil
+	})
 }
 
 // ConnectorNames returns a list of all the names of connectors currently in the
@@ -61,47 +770,421 @@ func (b *Balancer) ConnectorNames() []string {
 	return names
 }
 
-// randomConnectors returns the connectors in a random order.
-func (b *Balancer) randomConnectors() []driver.Connector {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+// TimeSinceLastSuccess returns how long it has been since the named connector
+// last connected successfully. It returns false if there is no such connector
+// or it has never connected.
+func (b *Balancer) TimeSinceLastSuccess(name string) (time.Duration, bool) {
+	c, ok := b.lookup(name)
+	if !ok {
+		return 0, false
+	}
+	last := c.info(b.now()).LastSuccess
+	if last.IsZero() {
+		return 0, false
+	}
+	return b.now().Sub(last), true
+}
 
-	var connectors []driver.Connector
-	for _, c := range b.mu.connectors {
-		connectors = append(connectors, c)
+// Describe returns a snapshot of every connector in the balancer sorted by
+// name. Each connector's state is read under its mutex so its counters are
+// consistent with each other.
+func (b *Balancer) Describe() []ConnectorInfo {
+	connectors := *b.connectors.Load()
+	now := b.now()
+	infos := make([]ConnectorInfo, 0, len(connectors))
+	for _, c := range connectors {
+		infos = append(infos, c.info(now))
 	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
 
-	return connectors
+// candidates returns the connectors that can be attempted in the order they
+// should be attempted.
+func (b *Balancer) candidates(ctx context.Context) ([]ConnectorInfo, error) {
+	connectors := *b.connectors.Load()
+	if len(connectors) == 0 {
+		return nil, ErrNoConnectors
+	}
+	hint, hinted := hintFromContext(ctx)
+	if hinted {
+		if err := b.checkHint(hint); err != nil {
+			return nil, err
+		}
+	} else {
+		hint, hinted = memoHintFromContext(ctx)
+	}
+	now := b.now()
+	if b.outliers != nil {
+		b.outliers.maybeDetect(b, connectors, now)
+	}
+	write := writeIntent(ctx)
+	subset := subsetFromContext(ctx)
+	selector := selectorFromContext(ctx)
+	weights := weightOverrideFromContext(ctx)
+	matched := 0
+	var candidates []ConnectorInfo
+	collect := func(connectors []*connector) {
+		candidates = make([]ConnectorInfo, 0, len(connectors))
+		for _, c := range connectors {
+			c.mu.Lock()
+			if (subset == nil || subset(c.mu.name)) && matchesTags(c.mu.tags, selector) {
+				matched++
+				b.adoptSharedLocked(c, now)
+				if c.availableLocked(now) {
+					info := c.infoLocked(now)
+					if w, ok := weights[info.Name]; ok {
+						info.Weight = w
+					}
+					if !write || info.Role == ReadWrite {
+						candidates = append(candidates, info)
+					}
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+	if b.sampleSize > 0 && len(connectors) > b.sampleSize && !hinted && subset == nil && selector == nil && b.preferred == "" {
+		collect(sample(b.rand, connectors, b.sampleSize))
+	}
+	if len(candidates) == 0 {
+		matched = 0
+		collect(connectors)
+	}
+
+	if matched == 0 {
+		return nil, ErrNoConnectors
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoHealthyConnectors
+	}
+
+	if b.rand != (mathRand{}) {
+		ctx = withRand(ctx, b.rand)
+	}
+	if b.firstAvailable {
+		sortBySuccess(candidates)
+	}
+	sortByTier(candidates)
+	forEachTier(candidates, func(tier []ConnectorInfo) {
+		if !b.firstAvailable {
+			b.order(ctx, tier)
+		}
+		if !write {
+			preferReadOnly(tier)
+		}
+	})
+	if !b.firstAvailable {
+		b.explore(candidates)
+	}
+	if b.preferred != "" {
+		preferFirst(candidates, b.preferred)
+	}
+	if hinted {
+		preferFirst(candidates, hint)
+	}
+	// The tier is taken before deprioritizing, which could otherwise move a
+	// connector from another tier to the front.
+	if b.failoverWithinTier {
+		candidates = sameTier(candidates)
+	}
+	// Recently failed and then avoided connectors are only attempted once all
+	// others have failed.
+	memory := retryMemoryFromContext(ctx)
+	rank := make([]int, len(candidates))
+	for i, c := range candidates {
+		rank[i] = deprioritized(c)
+		if rank[i] == 0 && memory.has(c.Name) {
+			rank[i] = 1
+		}
+	}
+	sort.Stable(rankOrder{candidates: candidates, rank: rank})
+	return candidates, nil
 }
 
-// Connect connects to a random driver.Connector. If the connection fails it
-// retries all the available connectors until one succeeds, or the context is
-// canceled.
+// connect connects to c and records the outcome.
+func (b *Balancer) connect(ctx context.Context, c *connector) (driver.Conn, error) {
+	probe, err := c.reserve()
+	if err != nil {
+		return nil, err
+	}
+	// Connect may have loaded the connectors before Drain started. The slot
+	// is reserved first so Drain either sees the dial or it's stopped here,
+	// before the connector can be closed.
+	if b.draining.Load() {
+		c.unreserve(probe)
+		return nil, ErrDraining
+	}
+	if err := b.acquireDial(ctx, c); err != nil {
+		c.unreserve(probe)
+		return nil, err
+	}
+
+	start := b.now()
+	dc, err := b.dial(ctx, c)
+	took := b.now().Sub(start)
+	c.releaseDial()
+	if err == nil && dc == nil {
+		err = ErrNilConn
+	}
+	if err == nil {
+		err = b.validate(ctx, c, dc)
+	}
+	labels := labelsFromContext(ctx)
+
+	b.updateConnector(c, func() {
+		c.mu.dialing--
+		if probe {
+			c.mu.probing = false
+		}
+		if b.connectSLO > 0 && took > b.connectSLO {
+			c.mu.sloViolations++
+		}
+		if err != nil {
+			b.recordFailureLocked(c, err)
+			return
+		}
+		b.recordSuccessLocked(c)
+		b.recordLabelsLocked(c, labels)
+		if c.mu.latency == 0 {
+			c.mu.latency = took
+		} else {
+			c.mu.latency += time.Duration(latencyDecay * float64(took-c.mu.latency))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	wrapped := &conn{Conn: dc, b: b, c: c, generation: c.generation.Load(), opened: b.now()}
+	wrapped.touch()
+	return wrapped, nil
+}
+
+// closed records that a connection from c that was opened at opened has been
+// closed.
+func (b *Balancer) closed(c *connector, opened time.Time) {
+	held := b.now().Sub(opened)
+	b.releaseTotal()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mu.open--
+	c.mu.holdTime.observe(held.Seconds())
+}
+
+// Connect connects to a driver.Connector picked by the strategy. If the
+// connection fails it retries all the available connectors until one
+// succeeds, or the context is canceled. See WithSetRetries for retrying the
+// whole set of connectors. If every attempt fails it returns a *ConnectError.
+// Middleware added with Use runs around it.
 func (b *Balancer) Connect(ctx context.Context) (driver.Conn, error) {
-	connectors := b.randomConnectors()
+	if chain := b.chain.Load(); chain != nil {
+		return (*chain)(ctx)
+	}
+	return b.connectBase(ctx)
+}
 
-	if len(connectors) == 0 {
-		return nil, ErrNoConnectors
+// connectBase is Connect without the middleware.
+func (b *Balancer) connectBase(ctx context.Context) (driver.Conn, error) {
+	if b.draining.Load() {
+		return nil, ErrDraining
+	}
+	if err := b.checkMinHealthy(ctx); err != nil {
+		return nil, err
+	}
+
+	start := b.now()
+	deadline, hasDeadline := ctx.Deadline()
+
+	ctx = b.memoHint(ctx)
+	if usesSpread(b.strategy) {
+		ctx = withSpreadCursor(ctx)
+	}
+	var attempts []Attempt
+	traced := b.tracer != nil && (b.traceSampler == nil || b.traceSampler())
+	if traced {
+		ctx = WithAttemptRecorder(ctx, &attempts)
+	}
+	conn, err := b.connectTotal(ctx)
+	b.recordConnect(start, deadline, hasDeadline, err)
+	if traced {
+		b.tracer(ctx, ConnectTrace{Attempts: attempts, Duration: b.now().Sub(start), Err: err})
+	}
+	if err == nil {
+		b.memoize(ctx, conn)
 	}
+	return conn, err
+}
+
+// connectTotal is connectRetries within the total connection limit. The slot
+// is released when the connection is closed.
+func (b *Balancer) connectTotal(ctx context.Context) (driver.Conn, error) {
+	if err := b.acquireTotal(ctx); err != nil {
+		return nil, err
+	}
+	conn, err := b.connectRetries(ctx)
+	if err != nil {
+		b.releaseTotal()
+	}
+	return conn, err
+}
 
-	var conn driver.Conn
+// connectRetries attempts the connectors, retrying the whole set if
+// configured.
+func (b *Balancer) connectRetries(ctx context.Context) (driver.Conn, error) {
+	if b.totalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.totalTimeout)
+		defer cancel()
+	}
+
+	errs := &ConnectError{max: b.maxReportedErrors, classify: b.classify}
 	var err error
-	for _, c := range connectors {
-		if err := ctx.Err(); err != nil {
-			return nil, err
+	for pass := 0; pass <= b.setRetries; pass++ {
+		if pass > 0 {
+			if err := b.sleepBackoff(ctx, pass); err != nil {
+				return nil, err
+			}
 		}
 
-		conn, err = c.Connect(ctx)
-		if err == nil {
+		conn, passErr := b.connectPass(ctx, errs)
+		if passErr == nil {
 			return conn, nil
 		}
+		// Keep the last connect error if every connector was ejected by the
+		// previous pass.
+		if passErr != ErrNoHealthyConnectors || err == nil {
+			err = passErr
+		}
+		// Only failed attempts and ejected connectors are worth retrying, other
+		// errors such as ErrNoConnectors or one from the connect hook aren't.
+		if passErr != errs && passErr != ErrNoHealthyConnectors || ctx.Err() != nil {
+			break
+		}
 	}
 	return nil, err
 }
 
-// Open is a thin wrapper around Connect.
-func (b *Balancer) Open(_ string) (driver.Conn, error) {
-	return b.Connect(context.Background())
+// connectPass attempts each of the candidates once until one succeeds.
+func (b *Balancer) connectPass(ctx context.Context, errs *ConnectError) (driver.Conn, error) {
+	var tried *connector
+	if b.fastPathOK(ctx) {
+		conn, c, err := b.connectFast(ctx, errs)
+		if conn != nil || err != nil {
+			return conn, err
+		}
+		tried = c
+	}
+
+	candidates, err := b.candidates(ctx)
+	if err != nil {
+		// The connector tried by the fast path may have been the last
+		// healthy one.
+		if tried != nil && (err == ErrNoHealthyConnectors || err == ErrNoConnectors) {
+			return nil, errs
+		}
+		return nil, err
+	}
+	if b.connectHook != nil {
+		if candidates, err = b.runConnectHook(ctx, candidates); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, c := range candidates {
+		if c.c == tried {
+			continue
+		}
+		conn, err := b.try(ctx, c.c, c.Name, errs)
+		if conn != nil || err != nil {
+			return conn, err
+		}
+	}
+	return nil, errs
+}
+
+// try makes an attempt to connect to c as part of a pass, recording the error
+// if it fails. It only returns an error if the pass should stop.
+func (b *Balancer) try(ctx context.Context, c *connector, name string, errs *ConnectError) (driver.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if errs.exhausted[c] {
+		return nil, nil
+	}
+	if !b.fitsDeadline(ctx, c) {
+		errs.add(name, ErrDeadlineTooSoon)
+		return nil, nil
+	}
+	if err := takeAttempt(ctx, errs); err != nil {
+		return nil, err
+	}
+
+	start := b.now()
+	conn, err := b.attempt(ctx, c)
+	attemptRecorderFromContext(ctx).record(Attempt{Name: name, Err: err, Duration: b.now().Sub(start)})
+	if err != nil {
+		errs.add(name, err)
+		retryMemoryFromContext(ctx).remember(name)
+		applyRetryPolicy(c, err, errs)
+		return nil, nil
+	}
+	return conn, nil
+}
+
+// Ping checks that at least one of the connectors is reachable. It connects to
+// a connector, pings the connection if it implements driver.Pinger and then
+// closes it. Like Connect, it fails over to the remaining connectors until one
+// succeeds, or the context is canceled.
+func (b *Balancer) Ping(ctx context.Context) error {
+	candidates, err := b.candidates(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range candidates {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err = ping(ctx, c.c)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// ping connects to c, pings the connection if possible and closes it.
+func ping(ctx context.Context, c driver.Connector) error {
+	conn, err := c.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	if conn == nil {
+		return ErrNilConn
+	}
+
+	if p, ok := conn.(driver.Pinger); ok {
+		if err := p.Ping(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	return conn.Close()
+}
+
+// Open is a thin wrapper around Connect. A non-empty name is used as a
+// connector hint, see WithConnectorHint.
+func (b *Balancer) Open(name string) (driver.Conn, error) {
+	ctx := context.Background()
+	if name != "" {
+		ctx = WithConnectorHint(ctx, name)
+	}
+	return b.Connect(ctx)
 }
 
 // Driver returns the balancer.
@@ -109,7 +1192,12 @@ func (b *Balancer) Driver() driver.Driver {
 	return b
 }
 
-// OpenConnector returns the balancer.
+// OpenConnector returns the balancer. A non-empty name is used as a connector
+// hint for every connect, so sql.Open(driverName, name) prefers the named
+// connector.
 func (b *Balancer) OpenConnector(name string) (driver.Connector, error) {
-	return b, nil
+	if name == "" {
+		return b, nil
+	}
+	return hintedConnector{b: b, name: name}, nil
 }
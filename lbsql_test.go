@@ -4,14 +4,25 @@ import (
 	"context"
 	"database/sql/driver"
 	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 type testConnector struct{}
 
-func (testConnector) Connect(context.Context) (driver.Conn, error) { return nil, nil }
+func (testConnector) Connect(context.Context) (driver.Conn, error) { return pingConn{}, nil }
 func (testConnector) Driver() driver.Driver                        { return nil }
 
+type nilConnector struct{}
+
+func (nilConnector) Connect(context.Context) (driver.Conn, error) { return nil, nil }
+func (nilConnector) Driver() driver.Driver                        { return nil }
+
 type errConnector struct{}
 
 func (errConnector) Connect(context.Context) (driver.Conn, error) { return nil, errors.New("err") }
@@ -34,14 +45,422 @@ func TestBalancer(t *testing.T) {
 		t.Fatalf("expected 1 connectors")
 	}
 
-	connectors := b.randomConnectors()
-	if connectors[0] != foo {
-		t.Fatalf("expected randomConnector = foo")
+	candidates, err := b.candidates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if candidates[0].c.Connector != foo {
+		t.Fatalf("expected candidate = foo")
+	}
+
+	b.Add("err", errConnector{})
+
+	if _, err := b.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type pingConn struct {
+	err error
+}
+
+func (pingConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not implemented") }
+func (pingConn) Close() error                        { return nil }
+func (pingConn) Begin() (driver.Tx, error)           { return nil, errors.New("not implemented") }
+func (c pingConn) Ping(context.Context) error        { return c.err }
+
+type pingConnector struct {
+	err error
+}
+
+func (c pingConnector) Connect(context.Context) (driver.Conn, error) {
+	return pingConn{err: c.err}, nil
+}
+func (pingConnector) Driver() driver.Driver { return nil }
+
+func TestBalancerNilConn(t *testing.T) {
+	b := NewBalancer(WithStrategy(nameStrategy{}))
+	b.Add("a", nilConnector{})
+	b.Add("b", pingConnector{})
+
+	c, err := b.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := c.(*conn).c.name(); name != "b" {
+		t.Fatalf("expected failover to b; got %s", name)
+	}
+	if info := b.Describe()[0]; info.Failures != 1 || !errors.Is(info.LastError, ErrNilConn) {
+		t.Fatalf("expected nil connection to be recorded as a failure; got %+v", info)
+	}
+
+	b.Remove("b")
+	if _, err := b.Connect(context.Background()); !errors.Is(err, ErrNilConn) {
+		t.Fatalf("expected %+v; got %+v", ErrNilConn, err)
+	}
+	if err := b.Ping(context.Background()); !errors.Is(err, ErrNilConn) {
+		t.Fatalf("expected %+v; got %+v", ErrNilConn, err)
+	}
+}
+
+func TestBalancerPing(t *testing.T) {
+	b := NewBalancer()
+	if err := b.Ping(context.Background()); err != ErrNoConnectors {
+		t.Fatalf("expected %+v; got %+v", ErrNoConnectors, err)
+	}
+
+	b.Add("down", pingConnector{err: errors.New("down")})
+	if err := b.Ping(context.Background()); err == nil {
+		t.Fatalf("expected error pinging down connector")
 	}
 
 	b.Add("err", errConnector{})
+	b.Add("up", pingConnector{})
+	for i := 0; i < 10; i++ {
+		if err := b.Ping(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBalancerDescribe(t *testing.T) {
+	now := time.Unix(100, 0)
+	b := NewBalancer(WithStrategy(nameStrategy{}), WithCircuitBreaker(2, time.Minute))
+	b.now = func() time.Time { return now }
+	b.Add("a", errConnector{})
+	b.Add("b", pingConnector{})
+	b.Add("c", pingConnector{})
+	b.SetWeight("b", 5)
+
+	var conns []driver.Conn
+	for i := 0; i < 5; i++ {
+		conn, err := b.Connect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns = append(conns, conn)
+	}
+	for _, conn := range conns[:2] {
+		if err := conn.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Closing twice must not change the open count again.
+	conns[0].Close()
+
+	infos := b.Describe()
+	if len(infos) != 3 {
+		t.Fatalf("expected 3 connectors; got %+v", infos)
+	}
+	a, bInfo, c := infos[0], infos[1], infos[2]
+	if a.Name != "a" || a.Failures != 2 || a.Connects != 0 || a.Health != Ejected ||
+		a.EffectiveWeight != 0 || !a.LastErrTime.Equal(now) || a.LastError == nil {
+		t.Fatalf("unexpected info for a: %+v", a)
+	}
+	if bInfo.Name != "b" || bInfo.Connects != 5 || bInfo.Open != 3 || bInfo.Failures != 0 ||
+		bInfo.Health != Healthy || bInfo.EffectiveWeight != 5 {
+		t.Fatalf("unexpected info for b: %+v", bInfo)
+	}
+	if c.Name != "c" || c.Connects != 0 || c.Open != 0 || c.Health != Healthy || c.EffectiveWeight != 1 {
+		t.Fatalf("unexpected info for c: %+v", c)
+	}
+}
+
+func TestBalancerAddAuto(t *testing.T) {
+	b := NewBalancer()
+	b.Add("conn-1", testConnector{})
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		names = append(names, b.AddAuto(testConnector{}))
+	}
+	want := []string{"conn-0", "conn-2", "conn-3"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected %+v; got %+v", want, names)
+	}
+
+	all := b.ConnectorNames()
+	sort.Strings(all)
+	want = []string{"conn-0", "conn-1", "conn-2", "conn-3"}
+	if !reflect.DeepEqual(all, want) {
+		t.Fatalf("expected %+v; got %+v", want, all)
+	}
+}
+
+type namedConnector struct {
+	testConnector
+	name string
+}
+
+func (c namedConnector) Name() string { return c.name }
+
+func TestBalancerAddNamed(t *testing.T) {
+	b := NewBalancer()
+	c := namedConnector{name: "primary"}
+	b.AddNamed(c)
+
+	if names := b.ConnectorNames(); !reflect.DeepEqual(names, []string{"primary"}) {
+		t.Fatalf("expected [primary]; got %+v", names)
+	}
+	if got, _ := b.lookup("primary"); got.Connector != c {
+		t.Fatalf("expected connector to be registered under its name")
+	}
+}
+
+func TestBalancerRename(t *testing.T) {
+	b := NewBalancer()
+	b.Add("old", pingConnector{})
+	b.Add("other", errConnector{})
+	b.Remove("other")
+	b.SetWeight("old", 3)
+	for i := 0; i < 2; i++ {
+		if _, err := b.Connect(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.Add("taken", pingConnector{})
+
+	if err := b.Rename("missing", "new"); !errors.Is(err, ErrConnectorNotFound) {
+		t.Fatalf("expected %+v; got %+v", ErrConnectorNotFound, err)
+	}
+	if err := b.Rename("old", "taken"); !errors.Is(err, ErrNameExists) {
+		t.Fatalf("expected %+v; got %+v", ErrNameExists, err)
+	}
+	if err := b.Rename("old", "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	infos := b.Describe()
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 connectors; got %+v", infos)
+	}
+	if info := infos[0]; info.Name != "new" || info.Connects != 2 || info.Open != 2 || info.Weight != 3 {
+		t.Fatalf("expected state to follow the new name; got %+v", info)
+	}
+	if _, ok := b.lookup("old"); ok {
+		t.Fatalf("expected old name to be removed")
+	}
+}
+
+func TestBalancerRemoveFunc(t *testing.T) {
+	b := NewBalancer()
+	for _, name := range []string{"east/a", "east/b", "west/a", "west/b", "west/c"} {
+		b.Add(name, testConnector{})
+	}
+
+	removed := b.RemoveFunc(func(name string, _ ConnectorInfo) bool {
+		return strings.HasPrefix(name, "west/")
+	})
+	if want := []string{"west/a", "west/b", "west/c"}; !reflect.DeepEqual(removed, want) {
+		t.Fatalf("expected %+v; got %+v", want, removed)
+	}
+	names := b.ConnectorNames()
+	sort.Strings(names)
+	if want := []string{"east/a", "east/b"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected %+v; got %+v", want, names)
+	}
+	if n := len(*b.connectors.Load()); n != 2 {
+		t.Fatalf("expected 2 connectors in snapshot; got %d", n)
+	}
+}
+
+func TestBalancerRemoveFuncCallback(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", testConnector{})
+	b.Add("b", testConnector{})
+
+	// The predicate can call back into the balancer without deadlocking.
+	removed := b.RemoveFunc(func(name string, _ ConnectorInfo) bool {
+		if name == "a" {
+			b.Add("a", testConnector{})
+		}
+		return b.Count() > 0 && len(b.Describe()) > 0
+	})
+	// a was replaced while the predicate ran, so it's kept.
+	if want := []string{"b"}; !reflect.DeepEqual(removed, want) {
+		t.Fatalf("expected %+v; got %+v", want, removed)
+	}
+}
+
+func TestBalancerTimeSinceLastSuccess(t *testing.T) {
+	now := time.Unix(100, 0)
+	b := NewBalancer()
+	b.now = func() time.Time { return now }
+	b.Add("a", pingConnector{})
+
+	if _, ok := b.TimeSinceLastSuccess("a"); ok {
+		t.Fatalf("expected no last success before connecting")
+	}
+	if _, ok := b.TimeSinceLastSuccess("missing"); ok {
+		t.Fatalf("expected no last success for a missing connector")
+	}
 
 	if _, err := b.Connect(context.Background()); err != nil {
 		t.Fatal(err)
 	}
+	now = now.Add(90 * time.Second)
+	d, ok := b.TimeSinceLastSuccess("a")
+	if !ok || d != 90*time.Second {
+		t.Fatalf("expected 90s since last success; got %s, %t", d, ok)
+	}
+	if info := b.Describe()[0]; !info.LastSuccess.Equal(time.Unix(100, 0)) {
+		t.Fatalf("unexpected last success: %s", info.LastSuccess)
+	}
+}
+
+type closerConnector struct {
+	testConnector
+	closed *int
+}
+
+func (c closerConnector) Close() error {
+	*c.closed++
+	return nil
+}
+
+func TestBalancerReset(t *testing.T) {
+	var closed int
+	b := NewBalancer(WithCloseOnReset(true))
+	b.Add("a", closerConnector{closed: &closed})
+	b.Add("b", errConnector{})
+	b.AddAuto(pingConnector{})
+	for i := 0; i < 3; i++ {
+		b.Connect(context.Background())
+	}
+
+	if err := b.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if n := b.Count(); n != 0 {
+		t.Fatalf("expected no connectors; got %d", n)
+	}
+	if closed != 1 {
+		t.Fatalf("expected closer connector to be closed once; got %d", closed)
+	}
+	if stats := b.Stats(); stats.Connects != 0 || stats.Failures != 0 || stats.DeadlineUsage.Count != 0 {
+		t.Fatalf("expected stats to be zeroed; got %+v", stats)
+	}
+	if _, err := b.Connect(context.Background()); err != ErrNoConnectors {
+		t.Fatalf("expected %+v; got %+v", ErrNoConnectors, err)
+	}
+
+	// Connectors added afterwards start with fresh state.
+	if name := b.AddAuto(pingConnector{}); name != "conn-0" {
+		t.Fatalf("expected names to restart at conn-0; got %s", name)
+	}
+	if info := b.Describe()[0]; info.Connects != 0 || info.Failures != 0 || info.Health != Healthy {
+		t.Fatalf("expected fresh connector state; got %+v", info)
+	}
+}
+
+func TestBalancerConcurrentChanges(t *testing.T) {
+	b := NewBalancer()
+	b.Add("static", pingConnector{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				conn, err := b.Connect(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				conn.Close()
+			}
+		}()
+	}
+	for j := 0; j < 100; j++ {
+		name := b.AddAuto(pingConnector{})
+		b.SetWeight(name, j)
+		b.Describe()
+		b.Remove(name)
+	}
+	wg.Wait()
+
+	if n := len(*b.connectors.Load()); n != 1 {
+		t.Fatalf("expected 1 connector in snapshot; got %d", n)
+	}
+}
+
+func BenchmarkConnect(b *testing.B) {
+	for _, n := range []int{1, 10, 100, 1000, 10000} {
+		b.Run(fmt.Sprintf("connectors=%d", n), func(b *testing.B) {
+			bal := NewBalancer()
+			for i := 0; i < n; i++ {
+				bal.AddAuto(pingConnector{})
+			}
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					conn, err := bal.Connect(ctx)
+					if err != nil {
+						b.Fatal(err)
+					}
+					conn.Close()
+				}
+			})
+		})
+	}
+}
+
+// connectorFunc is a driver.Connector that calls the function to connect.
+type connectorFunc func(context.Context) (driver.Conn, error)
+
+func (f connectorFunc) Connect(ctx context.Context) (driver.Conn, error) { return f(ctx) }
+func (connectorFunc) Driver() driver.Driver                              { return nil }
+
+func TestCloseOnReplace(t *testing.T) {
+	var closed int
+	b := NewBalancer(WithCloseOnReplace(true))
+	old := closerConnector{closed: &closed}
+	b.Add("a", old)
+	b.Add("a", old)
+	if closed != 0 {
+		t.Fatalf("expected re-adding the same connector to not close it; got %d closes", closed)
+	}
+
+	b.SetWeight("a", 5)
+	b.Add("a", pingConnector{})
+	if closed != 1 {
+		t.Fatalf("expected the replaced connector to be closed once; got %d closes", closed)
+	}
+	if info := b.Describe(); len(info) != 1 || info[0].Weight != 1 {
+		t.Fatalf("expected one connector with its state reset; got %+v", info)
+	}
+
+	// Connectors that can't be compared are still replaced.
+	b.Add("f", connectorFunc(func(context.Context) (driver.Conn, error) { return pingConn{}, nil }))
+	b.Add("f", connectorFunc(func(context.Context) (driver.Conn, error) { return pingConn{}, nil }))
+	if b.Count() != 2 {
+		t.Fatalf("expected 2 connectors; got %d", b.Count())
+	}
+}
+
+func TestRemoveDiscardsState(t *testing.T) {
+	b := NewBalancer(WithCircuitBreaker(1, time.Hour))
+	for i := 0; i < 1000; i++ {
+		name := fmt.Sprintf("conn-%d", i)
+		b.Add(name, errConnector{})
+		b.Connect(context.Background())
+		b.Remove(name)
+	}
+	if n := len(b.mu.connectors); n != 0 {
+		t.Fatalf("expected no connectors to be retained; got %d", n)
+	}
+	if n := len(*b.connectors.Load()); n != 0 {
+		t.Fatalf("expected an empty snapshot; got %d", n)
+	}
+
+	// Re-adding a removed name doesn't bring back its failures or ejection.
+	b.Add("conn-0", pingConnector{})
+	if info := b.Describe()[0]; info.Failures != 0 || info.LastError != nil || info.Health != Healthy {
+		t.Fatalf("expected fresh state; got %+v", info)
+	}
+	connectedName(t, b, context.Background())
 }
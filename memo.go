@@ -0,0 +1,104 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// maxMemoEntries bounds the number of keys remembered by WithConnectMemo.
+const maxMemoEntries = 1024
+
+type memoKey struct{}
+
+// memoHintKey is separate from the hint key so a remembered connector that
+// has since been removed is never checked by WithStrictHints.
+type memoHintKey struct{}
+
+// memoEntry is the connector a memo key connected to and when it's forgotten.
+type memoEntry struct {
+	name    string
+	expires time.Time
+}
+
+// WithConnectMemo makes Connect calls with the same key from WithMemoKey
+// prefer the connector the key last connected to, if that was within window.
+// This is for pools that expect back to back connects to be routed the same
+// way. Only the connector's name is remembered and used like a connector
+// hint, never the connection, and a remembered connector that has been
+// removed is ignored even with WithStrictHints.
+func WithConnectMemo(window time.Duration) Option {
+	return func(b *Balancer) {
+		b.memo.window = window
+	}
+}
+
+// WithMemoKey returns a context whose Connect calls share a memo key, see
+// WithConnectMemo.
+func WithMemoKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, memoKey{}, key)
+}
+
+// memoHint adds a connector hint for the context's memo key if it connected
+// within the memo window and there isn't a hint already.
+func (b *Balancer) memoHint(ctx context.Context) context.Context {
+	if b.memo.window <= 0 {
+		return ctx
+	}
+	key, ok := ctx.Value(memoKey{}).(string)
+	if !ok {
+		return ctx
+	}
+	if _, hinted := hintFromContext(ctx); hinted {
+		return ctx
+	}
+
+	b.memo.Lock()
+	defer b.memo.Unlock()
+
+	entry, ok := b.memo.entries[key]
+	if !ok || !b.now().Before(entry.expires) {
+		return ctx
+	}
+	return context.WithValue(ctx, memoHintKey{}, entry.name)
+}
+
+// memoHintFromContext returns the connector hint added by memoHint.
+func memoHintFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(memoHintKey{}).(string)
+	return name, ok
+}
+
+// memoize remembers the connector conn is from for the context's memo key.
+func (b *Balancer) memoize(ctx context.Context, dc driver.Conn) {
+	if b.memo.window <= 0 {
+		return
+	}
+	key, ok := ctx.Value(memoKey{}).(string)
+	if !ok {
+		return
+	}
+	c, ok := dc.(*conn)
+	if !ok {
+		return
+	}
+
+	now := b.now()
+	b.memo.Lock()
+	defer b.memo.Unlock()
+
+	if b.memo.entries == nil {
+		b.memo.entries = map[string]memoEntry{}
+	}
+	if _, ok := b.memo.entries[key]; !ok && len(b.memo.entries) >= maxMemoEntries {
+		for k, entry := range b.memo.entries {
+			if !now.Before(entry.expires) {
+				delete(b.memo.entries, k)
+			}
+		}
+		if len(b.memo.entries) >= maxMemoEntries {
+			return
+		}
+	}
+	b.memo.entries[key] = memoEntry{name: c.c.name(), expires: now.Add(b.memo.window)}
+}
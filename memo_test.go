@@ -0,0 +1,51 @@
+package lbsql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestConnectMemo(t *testing.T) {
+	now := time.Unix(100, 0)
+	b := NewBalancer(WithConnectMemo(time.Second))
+	b.now = func() time.Time { return now }
+	for i := 0; i < 10; i++ {
+		b.Add(fmt.Sprint(i), pingConnector{})
+	}
+
+	ctx := WithMemoKey(context.Background(), "pool-1")
+	first := connectedName(t, b, ctx)
+	for i := 0; i < 20; i++ {
+		if name := connectedName(t, b, ctx); name != first {
+			t.Fatalf("expected %q within the window; got %q", first, name)
+		}
+	}
+
+	// Once the window has passed the key is routed afresh.
+	names := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		now = now.Add(2 * time.Second)
+		names[connectedName(t, b, ctx)] = true
+	}
+	if len(names) < 2 {
+		t.Fatalf("expected the memo to expire; got %+v", names)
+	}
+}
+
+func TestConnectMemoStrictHints(t *testing.T) {
+	b := NewBalancer(WithConnectMemo(time.Minute), WithStrictHints(true))
+	b.Add("a", pingConnector{})
+	ctx := WithMemoKey(context.Background(), "session")
+	if name := connectedName(t, b, ctx); name != "a" {
+		t.Fatalf("expected a; got %s", name)
+	}
+
+	// The remembered connector being removed isn't a strict hint error.
+	b.Remove("a")
+	b.Add("b", pingConnector{})
+	if name := connectedName(t, b, ctx); name != "b" {
+		t.Fatalf("expected b; got %s", name)
+	}
+}
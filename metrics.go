@@ -0,0 +1,84 @@
+package lbsql
+
+import "database/sql/driver"
+
+// AddWithMetricTag adds a driver.Connector to the balancer with a metric tag,
+// such as its zone or shard. MetricsByTag aggregates connectors by their tag
+// instead of their name, which keeps the number of metric series down in
+// large fleets.
+func (b *Balancer) AddWithMetricTag(name string, c driver.Connector, tag string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.checkAddLocked(name, c) != nil {
+		return
+	}
+	// The tag is set before the connector is published so no connect is
+	// recorded without it.
+	conn := b.insertLocked(name, c)
+	conn.mu.Lock()
+	conn.mu.metricTag = tag
+	conn.mu.Unlock()
+	b.publishLocked()
+}
+
+// TagMetrics are the counters of all the connectors with the same metric tag.
+type TagMetrics struct {
+	// Connectors is the number of connectors with the tag.
+	Connectors int
+	// Healthy is the number of those connectors that are healthy.
+	Healthy int
+	// Open is the number of open connections from the connectors.
+	Open int
+	// Connects and Failures are the number of successful and failed connects
+	// to the connectors.
+	Connects int64
+	Failures int64
+}
+
+// MetricsByTag returns the counters of the connectors aggregated by their
+// metric tag, see AddWithMetricTag. Connectors added without a tag are
+// reported under their name.
+func (b *Balancer) MetricsByTag() map[string]TagMetrics {
+	return b.aggregate(func(info ConnectorInfo) (string, bool) {
+		if info.MetricTag == "" {
+			return info.Name, true
+		}
+		return info.MetricTag, true
+	})
+}
+
+// MetricsByTagKey returns the counters of the connectors aggregated by the
+// value of the tag key, see AddTagged. Connectors without the tag are left
+// out.
+func (b *Balancer) MetricsByTagKey(key string) map[string]TagMetrics {
+	return b.aggregate(func(info ConnectorInfo) (string, bool) {
+		value, ok := info.Tags[key]
+		return value, ok
+	})
+}
+
+// aggregate returns the counters of the connectors aggregated by the group
+// returned by group, leaving out the connectors it returns false for.
+func (b *Balancer) aggregate(group func(info ConnectorInfo) (string, bool)) map[string]TagMetrics {
+	now := b.now()
+	metrics := map[string]TagMetrics{}
+	for _, c := range *b.connectors.Load() {
+		info := c.info(now)
+		tag, ok := group(info)
+		if !ok {
+			continue
+		}
+
+		m := metrics[tag]
+		m.Connectors++
+		if info.Health == Healthy {
+			m.Healthy++
+		}
+		m.Open += info.Open
+		m.Connects += info.Connects
+		m.Failures += info.Failures
+		metrics[tag] = m
+	}
+	return metrics
+}
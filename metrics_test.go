@@ -0,0 +1,28 @@
+package lbsql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMetricsByTag(t *testing.T) {
+	b := NewBalancer(WithStrategy(nameStrategy{}))
+	b.AddWithMetricTag("us-1", errConnector{}, "us")
+	b.AddWithMetricTag("us-2", pingConnector{}, "us")
+	b.AddWithMetricTag("eu-1", pingConnector{}, "eu")
+	b.Add("zlocal", pingConnector{})
+
+	// eu-1 is attempted first by name.
+	connectedName(t, b, context.Background())
+	b.Remove("eu-1")
+	connectedName(t, b, context.Background())
+
+	want := map[string]TagMetrics{
+		"us":     {Connectors: 2, Healthy: 2, Connects: 1, Failures: 1},
+		"zlocal": {Connectors: 1, Healthy: 1},
+	}
+	if got := b.MetricsByTag(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v; got %+v", want, got)
+	}
+}
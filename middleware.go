@@ -0,0 +1,27 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// ConnectFunc connects, like Balancer.Connect.
+type ConnectFunc func(ctx context.Context) (driver.Conn, error)
+
+// ConnectMiddleware wraps a ConnectFunc with extra behavior such as logging or
+// tracing. It returns a ConnectFunc that is expected to call next.
+type ConnectMiddleware func(next ConnectFunc) ConnectFunc
+
+// Use adds middleware that runs around every Connect. Middleware runs in the
+// order it was added, so the first middleware is the outermost.
+func (b *Balancer) Use(mw ...ConnectMiddleware) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.mu.middleware = append(b.mu.middleware, mw...)
+	chain := ConnectFunc(b.connectBase)
+	for i := len(b.mu.middleware) - 1; i >= 0; i-- {
+		chain = b.mu.middleware[i](chain)
+	}
+	b.chain.Store(&chain)
+}
@@ -0,0 +1,38 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestUse(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a", pingConnector{})
+
+	var calls []string
+	record := func(name string) ConnectMiddleware {
+		return func(next ConnectFunc) ConnectFunc {
+			return func(ctx context.Context) (driver.Conn, error) {
+				calls = append(calls, name+" before")
+				conn, err := next(ctx)
+				calls = append(calls, name+" after")
+				return conn, err
+			}
+		}
+	}
+	b.Use(record("outer"))
+	b.Use(record("inner"))
+
+	if _, err := b.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"outer before", "inner before", "inner after", "outer after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("expected %+v; got %+v", want, calls)
+	}
+	if n := b.Stats().Connects; n != 1 {
+		t.Fatalf("expected the chain to end in Connect; got %d connects", n)
+	}
+}
@@ -0,0 +1,136 @@
+package lbsql
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// OutlierDetection configures ejecting connectors whose success rate is much
+// worse than the rest of the connectors, see WithOutlierDetection. Zero fields
+// use the defaults.
+type OutlierDetection struct {
+	// Interval is how often success rates are evaluated. The default is 10s.
+	Interval time.Duration
+	// MinRequests is the number of connects a connector needs in an interval to
+	// be evaluated. The default is 5.
+	MinRequests int
+	// Threshold is how far below the median success rate a connector's success
+	// rate must be for it to be ejected, e.g. 0.2 for 20 percentage points.
+	// The default is 0.2.
+	Threshold float64
+	// EjectionTime is how long outliers are ejected for. The default is 30s.
+	EjectionTime time.Duration
+	// MaxEjectionPercent is the maximum percentage of connectors that can be
+	// ejected as outliers at once. At least one connector can always be
+	// ejected. The default is 10.
+	MaxEjectionPercent int
+}
+
+// WithOutlierDetection periodically compares the success rates of the
+// connectors and ejects the ones that are significantly worse than the median.
+// Evaluation happens during Connect once the interval has passed.
+func WithOutlierDetection(cfg OutlierDetection) Option {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 5
+	}
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 0.2
+	}
+	if cfg.EjectionTime <= 0 {
+		cfg.EjectionTime = 30 * time.Second
+	}
+	if cfg.MaxEjectionPercent <= 0 {
+		cfg.MaxEjectionPercent = 10
+	}
+	return func(b *Balancer) {
+		b.outliers = &outlierDetector{cfg: cfg}
+	}
+}
+
+type outlierDetector struct {
+	cfg OutlierDetection
+
+	mu struct {
+		sync.Mutex
+
+		next time.Time
+	}
+}
+
+// maybeDetect runs outlier detection if the interval has passed since it last
+// ran. It doesn't block if detection is already running.
+func (d *outlierDetector) maybeDetect(b *Balancer, connectors []*connector, now time.Time) {
+	if !d.mu.TryLock() {
+		return
+	}
+	defer d.mu.Unlock()
+
+	if d.mu.next.IsZero() {
+		d.mu.next = now.Add(d.cfg.Interval)
+		return
+	}
+	if now.Before(d.mu.next) {
+		return
+	}
+	d.mu.next = now.Add(d.cfg.Interval)
+	d.detect(b, connectors, now)
+}
+
+// detect ejects the connectors with outlying success rates over the last
+// interval and returns expired ejections to selection.
+func (d *outlierDetector) detect(b *Balancer, connectors []*connector, now time.Time) {
+	type sample struct {
+		c    *connector
+		rate float64
+	}
+	var samples []sample
+	ejected := 0
+	for _, c := range connectors {
+		b.updateConnector(c, func() {
+			attempts, successes := c.mu.windowAttempts, c.mu.windowSuccesses
+			c.mu.windowAttempts, c.mu.windowSuccesses = 0, 0
+
+			if c.mu.outlier && !now.Before(c.mu.ejectedUntil) {
+				c.mu.outlier = false
+			}
+			if c.mu.outlier {
+				ejected++
+				return
+			}
+			if attempts >= d.cfg.MinRequests {
+				samples = append(samples, sample{c: c, rate: float64(successes) / float64(attempts)})
+			}
+		})
+	}
+	if len(samples) < 2 {
+		return
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].rate < samples[j].rate
+	})
+	median := samples[len(samples)/2].rate
+	if len(samples)%2 == 0 {
+		median = (median + samples[len(samples)/2-1].rate) / 2
+	}
+
+	maxEjected := len(connectors) * d.cfg.MaxEjectionPercent / 100
+	if maxEjected < 1 {
+		maxEjected = 1
+	}
+	for _, s := range samples {
+		if ejected >= maxEjected || s.rate >= median-d.cfg.Threshold {
+			return
+		}
+		c := s.c
+		b.updateConnector(c, func() {
+			c.mu.outlier = true
+			c.mu.ejectedUntil = now.Add(d.cfg.EjectionTime)
+		})
+		ejected++
+	}
+}
@@ -0,0 +1,87 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+// patternConnector fails every failEvery-th connect, or every connect if
+// failEvery is 1.
+type patternConnector struct {
+	failEvery int
+	n         int
+}
+
+func (c *patternConnector) Connect(context.Context) (driver.Conn, error) {
+	c.n++
+	if c.failEvery > 0 && c.n%c.failEvery == 0 {
+		return nil, errors.New("pattern")
+	}
+	return pingConn{}, nil
+}
+
+func (*patternConnector) Driver() driver.Driver { return nil }
+
+func TestOutlierDetection(t *testing.T) {
+	now := time.Unix(100, 0)
+	b := NewBalancer(WithOutlierDetection(OutlierDetection{
+		Interval:           time.Second,
+		MinRequests:        10,
+		EjectionTime:       time.Minute,
+		MaxEjectionPercent: 20,
+	}))
+	b.now = func() time.Time { return now }
+
+	connectors := map[string]*patternConnector{
+		"good1": {},
+		"good2": {},
+		"good3": {},
+		"meh":   {failEvery: 10},
+		"bad1":  {failEvery: 1},
+		"bad2":  {failEvery: 2},
+	}
+	for name, c := range connectors {
+		b.Add(name, c)
+	}
+	// Start the first interval.
+	b.candidates(context.Background())
+
+	ctx := context.Background()
+	for name := range connectors {
+		c, _ := b.lookup(name)
+		for i := 0; i < 10; i++ {
+			b.connect(ctx, c)
+		}
+	}
+
+	now = now.Add(time.Second)
+	candidates, err := b.candidates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 5 {
+		t.Fatalf("expected 1 connector to be ejected; got %d candidates", len(candidates))
+	}
+	for _, info := range b.Describe() {
+		want := Healthy
+		if info.Name == "bad1" {
+			want = Ejected
+		}
+		if info.Health != want {
+			t.Fatalf("expected %s to be %s; got %s", info.Name, want, info.Health)
+		}
+	}
+
+	// The ejection expires at the first evaluation after the ejection time.
+	now = now.Add(time.Minute)
+	candidates, err = b.candidates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 6 {
+		t.Fatalf("expected ejection to expire; got %d candidates", len(candidates))
+	}
+}
@@ -0,0 +1,23 @@
+package lbsql
+
+import "context"
+
+type weightOverrideKey struct{}
+
+// WithWeightOverride returns a context that makes Connect use the given
+// weights, keyed by connector name, instead of the configured ones. Connectors
+// that aren't in weights keep their configured weight. It only affects
+// strategies that use weights, such as WeightedStrategy.
+func WithWeightOverride(ctx context.Context, weights map[string]int) context.Context {
+	copied := make(map[string]int, len(weights))
+	for name, w := range weights {
+		copied[name] = w
+	}
+	return context.WithValue(ctx, weightOverrideKey{}, copied)
+}
+
+// weightOverrideFromContext returns the weights set by WithWeightOverride.
+func weightOverrideFromContext(ctx context.Context) map[string]int {
+	weights, _ := ctx.Value(weightOverrideKey{}).(map[string]int)
+	return weights
+}
@@ -0,0 +1,41 @@
+package lbsql
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWeightOverride(t *testing.T) {
+	b := NewBalancer(WithStrategy(WeightedStrategy{}))
+	b.Add("oltp", testConnector{})
+	b.Add("analytics", testConnector{})
+	b.SetWeight("oltp", 9)
+
+	weights := map[string]int{"oltp": 0, "analytics": 9}
+	ctx := WithWeightOverride(context.Background(), weights)
+	// Later changes to the map don't affect the context.
+	weights["oltp"] = 100
+
+	picked := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		candidates, err := b.candidates(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		picked[candidates[0].Name]++
+	}
+	if picked["analytics"] != 1000 {
+		t.Fatalf("expected the override to always pick analytics; got %+v", picked)
+	}
+
+	// Calls without the override use the configured weights.
+	candidates, err := b.candidates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range candidates {
+		if c.Name == "oltp" && c.Weight != 9 {
+			t.Fatalf("expected the configured weight; got %d", c.Weight)
+		}
+	}
+}
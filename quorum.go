@@ -0,0 +1,42 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInsufficientHealthy is returned by Connect when fewer connectors are
+// healthy than required by WithMinHealthy.
+var ErrInsufficientHealthy = errors.New("lbsql: not enough healthy connectors")
+
+type minHealthyKey struct{}
+
+// WithMinHealthy returns a context that makes Connect fail with
+// ErrInsufficientHealthy before attempting any connector if fewer than m
+// connectors in the balancer are healthy, for example to avoid writing during
+// a partial outage.
+func WithMinHealthy(ctx context.Context, m int) context.Context {
+	return context.WithValue(ctx, minHealthyKey{}, m)
+}
+
+// checkMinHealthy returns ErrInsufficientHealthy if the context requires more
+// healthy connectors than there are.
+func (b *Balancer) checkMinHealthy(ctx context.Context) error {
+	m, ok := ctx.Value(minHealthyKey{}).(int)
+	if !ok || m <= 0 {
+		return nil
+	}
+	healthy := 0
+	for _, c := range *b.connectors.Load() {
+		c.mu.Lock()
+		if c.healthLocked() == Healthy {
+			healthy++
+		}
+		c.mu.Unlock()
+	}
+	if healthy < m {
+		return fmt.Errorf("%w: %d healthy, %d required", ErrInsufficientHealthy, healthy, m)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMinHealthy(t *testing.T) {
+	var dials int
+	b := NewBalancer(WithFaultInjector(func(name string) error {
+		dials++
+		return nil
+	}))
+	for _, name := range []string{"a", "b", "c"} {
+		b.Add(name, pingConnector{})
+	}
+	ctx := WithMinHealthy(context.Background(), 2)
+	if _, err := b.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	b.SetHealth("a", false)
+	b.SetHealth("b", false)
+	dials = 0
+	if _, err := b.Connect(ctx); !errors.Is(err, ErrInsufficientHealthy) {
+		t.Fatalf("expected %+v; got %+v", ErrInsufficientHealthy, err)
+	}
+	if dials != 0 {
+		t.Fatalf("expected no dials; got %d", dials)
+	}
+	if _, err := b.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Connect(WithMinHealthy(context.Background(), 1)); err != nil {
+		t.Fatal(err)
+	}
+}
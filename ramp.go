@@ -0,0 +1,62 @@
+package lbsql
+
+import "time"
+
+// weightRamp is a linear change of a connector's weight over time, see
+// RampWeight.
+type weightRamp struct {
+	from, to   int
+	start, end time.Time
+}
+
+// at returns the weight along the ramp at now.
+func (r *weightRamp) at(now time.Time) int {
+	if !now.Before(r.end) {
+		return r.to
+	}
+	frac := float64(now.Sub(r.start)) / float64(r.end.Sub(r.start))
+	return int(float64(r.from) + frac*(float64(r.to)-float64(r.from)))
+}
+
+// RampWeight changes the weight of a connector from its current value to
+// target linearly over the given duration, for example to move traffic to a
+// new connector gradually. SetWeight or another RampWeight replaces a ramp in
+// progress. A duration of zero or less sets the weight immediately.
+func (b *Balancer) RampWeight(name string, target int, over time.Duration) {
+	c, ok := b.lookup(name)
+	if !ok {
+		return
+	}
+	if target < 0 {
+		target = 0
+	}
+	if over <= 0 {
+		b.SetWeight(name, target)
+		return
+	}
+
+	now := b.now()
+	b.updateConnector(c, func() {
+		c.mu.ramp = &weightRamp{
+			from:  c.weightLocked(now),
+			to:    target,
+			start: now,
+			end:   now.Add(over),
+		}
+	})
+}
+
+// weightLocked returns the weight of the connector at now, taking any ramp in
+// progress into account. The connector's mutex must be held.
+func (c *connector) weightLocked(now time.Time) int {
+	r := c.mu.ramp
+	if r == nil {
+		return c.mu.weight
+	}
+	if !now.Before(r.end) {
+		c.mu.weight = r.to
+		c.mu.ramp = nil
+		return c.mu.weight
+	}
+	return r.at(now)
+}
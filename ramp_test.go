@@ -0,0 +1,53 @@
+package lbsql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRampWeight(t *testing.T) {
+	now := time.Unix(100, 0)
+	b := NewBalancer()
+	b.now = func() time.Time { return now }
+	b.Add("canary", pingConnector{})
+	b.SetWeight("canary", 10)
+	weight := func() int {
+		t.Helper()
+		info := b.Describe()[0]
+		if info.EffectiveWeight != info.Weight {
+			t.Fatalf("expected the effective weight to follow the ramp; got %+v", info)
+		}
+		return info.Weight
+	}
+
+	b.RampWeight("canary", 110, 100*time.Second)
+	for _, step := range []struct {
+		after time.Duration
+		want  int
+	}{
+		{0, 10},
+		{25 * time.Second, 35},
+		{50 * time.Second, 60},
+		{99 * time.Second, 109},
+		{100 * time.Second, 110},
+		{time.Hour, 110},
+	} {
+		now = time.Unix(100, 0).Add(step.after)
+		if w := weight(); w != step.want {
+			t.Fatalf("expected a weight of %d after %s; got %d", step.want, step.after, w)
+		}
+	}
+
+	// Ramps down start from the current weight and are replaced by SetWeight.
+	start := now
+	b.RampWeight("canary", 0, 10*time.Second)
+	now = start.Add(5 * time.Second)
+	if w := weight(); w != 55 {
+		t.Fatalf("expected 55 halfway down; got %d", w)
+	}
+	b.SetWeight("canary", 7)
+	now = start.Add(time.Hour)
+	if w := weight(); w != 7 {
+		t.Fatalf("expected SetWeight to cancel the ramp; got %d", w)
+	}
+}
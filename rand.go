@@ -0,0 +1,75 @@
+package lbsql
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+)
+
+// randSource is the source of randomness used to order connectors.
+type randSource interface {
+	Intn(n int) int
+	Int63n(n int64) int64
+	Float64() float64
+	Shuffle(n int, swap func(i, j int))
+}
+
+// mathRand uses the top level math/rand functions, which are safe for
+// concurrent use.
+type mathRand struct{}
+
+func (mathRand) Intn(n int) int                     { return rand.Intn(n) }
+func (mathRand) Int63n(n int64) int64               { return rand.Int63n(n) }
+func (mathRand) Float64() float64                   { return rand.Float64() }
+func (mathRand) Shuffle(n int, swap func(i, j int)) { rand.Shuffle(n, swap) }
+
+// cryptoRand draws from crypto/rand. The source is stateless, so the
+// rand.Rand built on it is safe for concurrent use by the methods in
+// randSource.
+var cryptoRand randSource = rand.New(cryptoSource{})
+
+// cryptoSource is a rand.Source64 backed by crypto/rand.
+type cryptoSource struct{}
+
+func (cryptoSource) Seed(int64) {}
+
+func (s cryptoSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic("lbsql: reading crypto/rand: " + err.Error())
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// WithCryptoRand makes the built in strategies order connectors using
+// crypto/rand instead of math/rand, so the order can't be predicted from
+// previous orders. This makes selection noticeably slower.
+func WithCryptoRand(crypto bool) Option {
+	return func(b *Balancer) {
+		b.rand = mathRand{}
+		if crypto {
+			b.rand = cryptoRand
+		}
+	}
+}
+
+type randKey struct{}
+
+// withRand returns a context that makes the built in strategies use r.
+func withRand(ctx context.Context, r randSource) context.Context {
+	return context.WithValue(ctx, randKey{}, r)
+}
+
+// randFromContext returns the source of randomness set by withRand, or
+// math/rand if there isn't one.
+func randFromContext(ctx context.Context) randSource {
+	if r, ok := ctx.Value(randKey{}).(randSource); ok {
+		return r
+	}
+	return mathRand{}
+}
@@ -0,0 +1,53 @@
+package lbsql
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCryptoRand(t *testing.T) {
+	for _, s := range []Strategy{RandomStrategy{}, WeightedStrategy{}, StickyStrategy{}} {
+		b := NewBalancer(WithCryptoRand(true), WithStrategy(s), WithTopK(2))
+		for i := 0; i < 5; i++ {
+			b.AddAuto(pingConnector{})
+		}
+
+		picked := map[string]int{}
+		for i := 0; i < 500; i++ {
+			names := candidateNames(t, b)
+			if len(names) != 5 {
+				t.Fatalf("expected all connectors to be candidates; got %+v", names)
+			}
+			picked[names[0]]++
+		}
+		if len(picked) != 5 {
+			t.Fatalf("%T: expected every connector to be picked first; got %+v", s, picked)
+		}
+	}
+}
+
+func TestRandFromContext(t *testing.T) {
+	if r := randFromContext(context.Background()); r != (mathRand{}) {
+		t.Fatalf("expected math/rand by default; got %T", r)
+	}
+	if r := randFromContext(withRand(context.Background(), cryptoRand)); r != cryptoRand {
+		t.Fatalf("expected crypto/rand; got %T", r)
+	}
+}
+
+func BenchmarkShuffle(b *testing.B) {
+	for _, r := range []struct {
+		name string
+		rand randSource
+	}{
+		{"math", mathRand{}},
+		{"crypto", cryptoRand},
+	} {
+		b.Run(r.name, func(b *testing.B) {
+			candidates := make([]ConnectorInfo, 10)
+			for i := 0; i < b.N; i++ {
+				shuffle(r.rand, candidates)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package lbsql
+
+import "sort"
+
+// Ranked returns a snapshot of every connector in the balancer like Describe,
+// sorted from the best connector to target to the worst. Connectors are ranked
+// by health first, healthy before warming before ejected, and then by their
+// load plus their recent error rate, lowest first, see ConnectorInfo.Load and
+// ConnectorInfo.ErrorRate. Ties are sorted by name.
+func (b *Balancer) Ranked() []ConnectorInfo {
+	infos := b.Describe()
+	busiest := 0
+	for _, info := range infos {
+		if info.MaxOpen <= 0 && info.Open > busiest {
+			busiest = info.Open
+		}
+	}
+	penalty := make(map[*connector]float64, len(infos))
+	for _, info := range infos {
+		load := info.Load()
+		if info.MaxOpen <= 0 && busiest > 0 {
+			// Connectors without a limit are compared to the busiest
+			// of them.
+			load = float64(info.Open) / float64(busiest)
+		}
+		penalty[info.c] = load + info.ErrorRate()
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		if ri, rj := healthRank(infos[i].Health), healthRank(infos[j].Health); ri != rj {
+			return ri < rj
+		}
+		return penalty[infos[i].c] < penalty[infos[j].c]
+	})
+	return infos
+}
+
+// healthRank orders the health states from best to worst.
+func healthRank(h Health) int {
+	switch h {
+	case Healthy:
+		return 0
+	case Warming:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Load returns the fraction of the connector's capacity that is in use, see
+// SetMaxOpen. Connectors without a limit have a load of zero.
+func (i ConnectorInfo) Load() float64 {
+	if i.MaxOpen <= 0 {
+		return 0
+	}
+	if i.Open >= i.MaxOpen {
+		return 1
+	}
+	return float64(i.Open) / float64(i.MaxOpen)
+}
+
+// ErrorRate returns the fraction of the connects within the error window that
+// failed, see WithErrorWindow, or zero if there were none.
+func (i ConnectorInfo) ErrorRate() float64 {
+	total := i.RecentFailures + i.RecentConnects
+	if total == 0 {
+		return 0
+	}
+	return float64(i.RecentFailures) / float64(total)
+}
@@ -0,0 +1,44 @@
+package lbsql
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRanked(t *testing.T) {
+	b := NewBalancer()
+	b.Add("idle", pingConnector{})
+	b.Add("busy", pingConnector{})
+	b.SetMaxOpen("busy", 4)
+	b.Add("failing", errConnector{})
+	b.Add("down", pingConnector{})
+	b.SetHealth("down", false)
+
+	ctx := WithConnectorHint(context.Background(), "busy")
+	for i := 0; i < 2; i++ {
+		if _, err := b.Connect(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The failed attempt falls over to another connector, which is closed
+	// again.
+	conn, err := b.Connect(WithConnectorHint(context.Background(), "failing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	want := []string{"idle", "busy", "failing", "down"}
+	ranked := b.Ranked()
+	for i, info := range ranked {
+		if info.Name != want[i] {
+			t.Fatalf("expected %+v; got %s at %d", want, info.Name, i)
+		}
+	}
+	if load := ranked[1].Load(); load != 0.5 {
+		t.Fatalf("expected a load of 0.5; got %f", load)
+	}
+	if rate := ranked[2].ErrorRate(); rate != 1 {
+		t.Fatalf("expected an error rate of 1; got %f", rate)
+	}
+}
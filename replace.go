@@ -0,0 +1,36 @@
+package lbsql
+
+import "database/sql/driver"
+
+// ReplaceAll replaces the set of connectors with connectors, keyed by name, in
+// a single step. Connect sees either the old set or the new one and never an
+// empty set in between, which makes it the way to apply updates from service
+// discovery. Connectors that are in both sets under the same name keep their
+// state, the others are added and removed as with Add and Remove. With
+// WithRejectDuplicateConnectors it returns ErrDuplicateConnector and leaves
+// the set unchanged if a connector is in connectors under more than one name.
+func (b *Balancer) ReplaceAll(connectors map[string]driver.Connector) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.waitUnfrozenLocked(); err != nil {
+		return err
+	}
+	if err := b.duplicateIn(connectors); err != nil {
+		return err
+	}
+
+	for name := range b.mu.connectors {
+		if _, ok := connectors[name]; !ok {
+			delete(b.mu.connectors, name)
+		}
+	}
+	for name, c := range connectors {
+		if old, ok := b.mu.connectors[name]; ok && sameConnector(old.Connector, c) {
+			continue
+		}
+		b.insertLocked(name, c)
+	}
+	b.publishLocked()
+	return nil
+}
@@ -0,0 +1,67 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestReplaceAll(t *testing.T) {
+	b := NewBalancer()
+	keep := &closeConnector{name: "keep"}
+	b.Add("keep", keep)
+	b.Add("old", pingConnector{})
+	b.SetWeight("keep", 5)
+
+	if err := b.ReplaceAll(map[string]driver.Connector{
+		"keep": keep,
+		"new":  pingConnector{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	info := b.Describe()
+	if len(info) != 2 || info[0].Name != "keep" || info[1].Name != "new" {
+		t.Fatalf("expected keep and new; got %+v", info)
+	}
+	if info[0].Weight != 5 {
+		t.Fatalf("expected keep to keep its state; got %+v", info[0])
+	}
+}
+
+func TestReplaceAllConcurrent(t *testing.T) {
+	b := NewBalancer()
+	b.Add("a-0", pingConnector{})
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				c, err := b.Connect(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				c.Close()
+			}
+		}()
+	}
+
+	for i := 1; i <= 200; i++ {
+		b.ReplaceAll(map[string]driver.Connector{
+			fmt.Sprintf("a-%d", i): pingConnector{},
+			fmt.Sprintf("b-%d", i): pingConnector{},
+		})
+	}
+	close(done)
+	wg.Wait()
+}
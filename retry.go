@@ -0,0 +1,159 @@
+package lbsql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultSetRetryBackoff is the default wait before the first retry of the
+// whole set of connectors.
+const defaultSetRetryBackoff = 100 * time.Millisecond
+
+// maxSetRetryBackoff caps the doubling of the set retry backoff.
+const maxSetRetryBackoff = time.Minute
+
+// WithSetRetries makes Connect retry the whole set of connectors up to n more
+// times when every connector fails. Connect waits between each pass, starting
+// at the backoff set by WithSetRetryBackoff and doubling each time up to a
+// minute. The context still bounds the total time spent.
+func WithSetRetries(n int) Option {
+	return func(b *Balancer) {
+		b.setRetries = n
+	}
+}
+
+// WithSetRetryBackoff sets the wait before the first retry of the whole set of
+// connectors, see WithSetRetries. The default is 100ms.
+func WithSetRetryBackoff(d time.Duration) Option {
+	return func(b *Balancer) {
+		b.setRetryBackoff = d
+	}
+}
+
+// setRetryWait returns the wait before the given retry pass, which doubles
+// with each pass up to maxSetRetryBackoff, or up to the backoff itself if
+// that's longer.
+func (b *Balancer) setRetryWait(pass int) time.Duration {
+	d := b.setRetryBackoff
+	for i := 1; i < pass && d > 0 && d < maxSetRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > maxSetRetryBackoff && b.setRetryBackoff <= maxSetRetryBackoff {
+		d = maxSetRetryBackoff
+	}
+	return d
+}
+
+// sleepBackoff waits before the given retry pass, returning early with the
+// context's error if it's canceled.
+func (b *Balancer) sleepBackoff(ctx context.Context, pass int) error {
+	d := b.setRetryWait(pass)
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+type retryMemoryKey struct{}
+
+// retryMemory is the set of connectors that failed during Connect calls
+// sharing a context from WithRetryMemory.
+type retryMemory struct {
+	mu     sync.Mutex
+	failed map[string]bool
+}
+
+// WithRetryMemory returns a context that remembers which connectors failed to
+// connect in Connect calls made with it. Later Connect calls with the context
+// attempt those connectors only after the others, so an application level
+// retry loop doesn't keep hitting the same broken backend.
+func WithRetryMemory(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryMemoryKey{}, &retryMemory{failed: map[string]bool{}})
+}
+
+// retryMemoryFromContext returns the memory set by WithRetryMemory, or nil.
+func retryMemoryFromContext(ctx context.Context) *retryMemory {
+	m, _ := ctx.Value(retryMemoryKey{}).(*retryMemory)
+	return m
+}
+
+// remember records that the named connector failed.
+func (m *retryMemory) remember(name string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.failed[name] = true
+}
+
+// has reports whether the named connector failed.
+func (m *retryMemory) has(name string) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.failed[name]
+}
+
+// RetryPolicy limits how often a connector is attempted within a single
+// Connect call, overriding WithSetRetries for that connector, see
+// SetRetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts is the most times the connector is attempted per Connect
+	// call, across set retries. 1 disables retries and 0 means no limit.
+	MaxAttempts int
+	// Retryable reports whether the connector may be attempted again after
+	// failing with err. If nil every error is retryable.
+	Retryable func(err error) bool
+}
+
+// SetRetryPolicy sets the retry policy of the named connector. Once the
+// connector has used up its attempts or failed with an error that isn't
+// retryable, Connect only fails over to the other connectors. The zero
+// RetryPolicy goes back to the balancer's retry behavior.
+func (b *Balancer) SetRetryPolicy(name string, policy RetryPolicy) {
+	c, ok := b.lookup(name)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mu.retryPolicy = policy
+}
+
+// applyRetryPolicy records a failed attempt of c against its retry policy,
+// marking it exhausted in errs once it can't be attempted again.
+func applyRetryPolicy(c *connector, err error, errs *ConnectError) {
+	c.mu.Lock()
+	policy := c.mu.retryPolicy
+	c.mu.Unlock()
+	if policy.MaxAttempts <= 0 && policy.Retryable == nil {
+		return
+	}
+
+	if errs.attempts == nil {
+		errs.attempts = map[*connector]int{}
+		errs.exhausted = map[*connector]bool{}
+	}
+	errs.attempts[c]++
+	if policy.MaxAttempts > 0 && errs.attempts[c] >= policy.MaxAttempts ||
+		policy.Retryable != nil && !policy.Retryable(err) {
+		errs.exhausted[c] = true
+	}
+}
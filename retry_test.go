@@ -0,0 +1,138 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyConnector fails the first n connects.
+type flakyConnector struct {
+	mu       sync.Mutex
+	n        int
+	attempts int
+}
+
+func (c *flakyConnector) Connect(context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.attempts++
+	if c.attempts <= c.n {
+		return nil, errors.New("flaky")
+	}
+	return pingConn{}, nil
+}
+
+func (*flakyConnector) Driver() driver.Driver { return nil }
+
+func TestSetRetries(t *testing.T) {
+	a := &flakyConnector{n: 1}
+	c := &flakyConnector{n: 1}
+
+	b := NewBalancer()
+	b.Add("a", a)
+	b.Add("c", c)
+	if _, err := b.Connect(context.Background()); err == nil {
+		t.Fatalf("expected error without set retries")
+	}
+
+	a.attempts, c.attempts = 0, 0
+	b = NewBalancer(WithSetRetries(1), WithSetRetryBackoff(time.Millisecond))
+	b.Add("a", a)
+	b.Add("c", c)
+	if _, err := b.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if total := a.attempts + c.attempts; total != 3 {
+		t.Fatalf("expected 3 attempts; got %d", total)
+	}
+}
+
+func TestSetRetriesContext(t *testing.T) {
+	b := NewBalancer(WithSetRetries(10), WithSetRetryBackoff(time.Hour))
+	b.Add("err", errConnector{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.Connect(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected %+v; got %+v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestSetRetryWaitCapped(t *testing.T) {
+	b := NewBalancer(WithSetRetries(100), WithSetRetryBackoff(time.Second))
+	if d := b.setRetryWait(3); d != 4*time.Second {
+		t.Fatalf("expected 4s; got %s", d)
+	}
+	for _, pass := range []int{35, 64, 100} {
+		if d := b.setRetryWait(pass); d != maxSetRetryBackoff {
+			t.Fatalf("expected pass %d to wait %s; got %s", pass, maxSetRetryBackoff, d)
+		}
+	}
+
+	b = NewBalancer(WithSetRetryBackoff(time.Hour))
+	if d := b.setRetryWait(10); d != time.Hour {
+		t.Fatalf("expected a backoff above the cap to be kept; got %s", d)
+	}
+}
+
+func TestRetryMemory(t *testing.T) {
+	b := NewBalancer(WithStrategy(nameStrategy{}))
+	a := &flakyConnector{n: 1}
+	b.Add("a", a)
+	b.Add("b", pingConnector{})
+
+	ctx := WithRetryMemory(context.Background())
+	if name := connectedName(t, b, ctx); name != "b" {
+		t.Fatalf("expected failover to b; got %s", name)
+	}
+	// a works again, but the retry tries b first.
+	if name := connectedName(t, b, ctx); name != "b" || a.attempts != 1 {
+		t.Fatalf("expected b to be attempted before a; got %s after %d attempts of a", name, a.attempts)
+	}
+
+	// Other contexts aren't affected.
+	if name := connectedName(t, b, context.Background()); name != "a" {
+		t.Fatalf("expected a; got %s", name)
+	}
+}
+
+func TestSetRetryPolicy(t *testing.T) {
+	errPermanent := errors.New("permanent")
+	b := NewBalancer(WithStrategy(nameStrategy{}), WithSetRetries(2), WithSetRetryBackoff(0))
+	dials := map[string]int{}
+	for _, name := range []string{"a", "b", "c"} {
+		name := name
+		b.Add(name, connectorFunc(func(context.Context) (driver.Conn, error) {
+			dials[name]++
+			if name == "c" {
+				return nil, errPermanent
+			}
+			return nil, errors.New("down")
+		}))
+	}
+	b.SetRetryPolicy("a", RetryPolicy{MaxAttempts: 1})
+	b.SetRetryPolicy("c", RetryPolicy{Retryable: func(err error) bool { return err != errPermanent }})
+	b.SetRetryPolicy("missing", RetryPolicy{MaxAttempts: 1})
+
+	if _, err := b.Connect(context.Background()); err == nil {
+		t.Fatal("expected every connector to fail")
+	}
+	want := map[string]int{"a": 1, "b": 3, "c": 1}
+	if !reflect.DeepEqual(dials, want) {
+		t.Fatalf("expected dials %+v; got %+v", want, dials)
+	}
+
+	// The policy applies per Connect call.
+	b.SetRetryPolicy("c", RetryPolicy{})
+	b.Connect(context.Background())
+	want = map[string]int{"a": 2, "b": 6, "c": 4}
+	if !reflect.DeepEqual(dials, want) {
+		t.Fatalf("expected dials %+v; got %+v", want, dials)
+	}
+}
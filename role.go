@@ -0,0 +1,75 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"sort"
+)
+
+// Role is whether a connector can be used for writes.
+type Role int
+
+const (
+	// ReadWrite connectors are used for reads and writes. Connectors added
+	// with Add are ReadWrite.
+	ReadWrite Role = iota
+	// ReadOnly connectors are only used for reads.
+	ReadOnly
+)
+
+func (r Role) String() string {
+	switch r {
+	case ReadWrite:
+		return "read-write"
+	case ReadOnly:
+		return "read-only"
+	default:
+		return "unknown"
+	}
+}
+
+// AddRole adds a driver.Connector to the balancer with the given role. Connect
+// calls with a context from WithWriteIntent only use ReadWrite connectors,
+// while other calls use either, attempting ReadOnly connectors first.
+func (b *Balancer) AddRole(name string, c driver.Connector, role Role) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.checkAddLocked(name, c) != nil {
+		return
+	}
+	// The role is set before the connector is published so Connect never
+	// sees it without one.
+	conn := b.insertLocked(name, c)
+	conn.mu.Lock()
+	conn.mu.role = role
+	conn.mu.Unlock()
+	if role != ReadWrite {
+		b.ordered.Store(true)
+	}
+	b.publishLocked()
+}
+
+type writeIntentKey struct{}
+
+// WithWriteIntent returns a context that marks whether the connection will be
+// used for writes. Connections for writes are only made to ReadWrite
+// connectors, see AddRole.
+func WithWriteIntent(ctx context.Context, write bool) context.Context {
+	return context.WithValue(ctx, writeIntentKey{}, write)
+}
+
+// writeIntent returns whether the context was marked for writes by
+// WithWriteIntent.
+func writeIntent(ctx context.Context) bool {
+	write, _ := ctx.Value(writeIntentKey{}).(bool)
+	return write
+}
+
+// preferReadOnly moves the ReadOnly candidates ahead of the ReadWrite ones,
+// keeping their order otherwise.
+func preferReadOnly(candidates []ConnectorInfo) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Role == ReadOnly && candidates[j].Role != ReadOnly
+	})
+}
@@ -0,0 +1,40 @@
+package lbsql
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAddRole(t *testing.T) {
+	b := NewBalancer()
+	b.AddRole("primary", pingConnector{}, ReadWrite)
+	b.AddRole("replica1", pingConnector{}, ReadOnly)
+	b.AddRole("replica2", pingConnector{}, ReadOnly)
+
+	write := WithWriteIntent(context.Background(), true)
+	for i := 0; i < 20; i++ {
+		if name := connectedName(t, b, write); name != "primary" {
+			t.Fatalf("expected writes to go to primary; got %s", name)
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		if name := connectedName(t, b, context.Background()); name == "primary" {
+			t.Fatalf("expected reads to prefer replicas")
+		}
+	}
+
+	// Reads fall back to read-write connectors.
+	b.Remove("replica1")
+	b.Remove("replica2")
+	if name := connectedName(t, b, WithWriteIntent(context.Background(), false)); name != "primary" {
+		t.Fatalf("expected reads to fall back to primary; got %s", name)
+	}
+
+	// Writes never use read-only connectors.
+	b.Remove("primary")
+	b.AddRole("replica", pingConnector{}, ReadOnly)
+	if _, err := b.Connect(write); err != ErrNoHealthyConnectors {
+		t.Fatalf("expected %+v; got %+v", ErrNoHealthyConnectors, err)
+	}
+}
@@ -0,0 +1,36 @@
+package lbsql
+
+// WithSampleSize makes Connect only consider k connectors picked at random on
+// each call, ordering and failing over among just those, which bounds the
+// work per call in large fleets. If none of the sampled connectors can be
+// attempted every connector is considered instead. Sampling is skipped when
+// the connector to use first is known, with WithPreferred, a connector hint
+// or a subset.
+func WithSampleSize(k int) Option {
+	return func(b *Balancer) {
+		b.sampleSize = k
+	}
+}
+
+// sample returns k distinct connectors picked at random. k must be less than
+// the number of connectors.
+func sample(r randSource, connectors []*connector, k int) []*connector {
+	picked := make([]*connector, 0, k)
+	for len(picked) < k {
+		c := connectors[r.Intn(len(connectors))]
+		if !containsConnector(picked, c) {
+			picked = append(picked, c)
+		}
+	}
+	return picked
+}
+
+// containsConnector reports whether c is in connectors.
+func containsConnector(connectors []*connector, c *connector) bool {
+	for _, other := range connectors {
+		if other == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSampleSize(t *testing.T) {
+	b := NewBalancer(WithSampleSize(3))
+	for i := 0; i < 100; i++ {
+		b.Add(fmt.Sprint(i), testConnector{})
+	}
+
+	picked := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		candidates, err := b.candidates(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(candidates) != 3 {
+			t.Fatalf("expected 3 candidates; got %d", len(candidates))
+		}
+		picked[candidates[0].Name]++
+	}
+	for i := 0; i < 100; i++ {
+		if n := picked[fmt.Sprint(i)]; n < 40 || n > 200 {
+			t.Fatalf("expected an even spread; got %d picks for %d", n, i)
+		}
+	}
+}
+
+func TestSampleSizeUnhealthy(t *testing.T) {
+	b := NewBalancer(WithSampleSize(1), WithCircuitBreaker(1, time.Minute))
+	b.Add("bad", failConnector{errors.New("down")})
+	b.Add("good", pingConnector{})
+	connectedName(t, b, WithConnectorHint(context.Background(), "bad"))
+
+	// Once bad is ejected, sampling only it falls back to every connector.
+	for i := 0; i < 20; i++ {
+		if names := candidateNames(t, b); len(names) != 1 || names[0] != "good" {
+			t.Fatalf("expected good; got %+v", names)
+		}
+	}
+}
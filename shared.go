@@ -0,0 +1,93 @@
+package lbsql
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SharedHealth shares circuit breaker verdicts between balancers over the
+// same backends, see WithSharedHealth. It's safe for concurrent use.
+type SharedHealth struct {
+	// gen is incremented for every verdict, so balancers only look up
+	// verdicts when there are new ones.
+	gen atomic.Uint64
+
+	mu struct {
+		sync.Mutex
+
+		verdicts map[string]sharedVerdict
+	}
+}
+
+// sharedVerdict is the last breaker verdict for a backend.
+type sharedVerdict struct {
+	gen     uint64
+	down    bool
+	retryAt time.Time
+}
+
+// NewSharedHealth returns an empty SharedHealth.
+func NewSharedHealth() *SharedHealth {
+	s := &SharedHealth{}
+	s.mu.verdicts = map[string]sharedVerdict{}
+	return s
+}
+
+// WithSharedHealth shares the balancer's circuit breaker verdicts with every
+// other balancer using s, so once one balancer's breaker opens for a backend
+// the others eject it until the same cooldown has passed instead of each
+// finding out with failed connects of their own, and once one balancer's
+// breaker closes again the others probe the backend on their next Connect.
+// Backends are identified by connector name, so they must be added under the
+// same name to every balancer. It only applies to balancers with
+// WithCircuitBreaker.
+func WithSharedHealth(s *SharedHealth) Option {
+	return func(b *Balancer) {
+		b.sharedHealth = s
+	}
+}
+
+// publish records a breaker verdict for the named backend. It's a no-op on a
+// nil SharedHealth.
+func (s *SharedHealth) publish(name string, down bool, retryAt time.Time) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mu.verdicts[name] = sharedVerdict{gen: s.gen.Add(1), down: down, retryAt: retryAt}
+}
+
+// adoptSharedLocked applies the shared verdict for c to its breaker if there
+// is a new one. The connector's mutex must be held.
+func (b *Balancer) adoptSharedLocked(c *connector, now time.Time) {
+	s := b.sharedHealth
+	if s == nil || b.breakerFailures <= 0 {
+		return
+	}
+	gen := s.gen.Load()
+	if c.mu.sharedGen == gen {
+		return
+	}
+	s.mu.Lock()
+	v, ok := s.mu.verdicts[c.mu.name]
+	s.mu.Unlock()
+	seen := c.mu.sharedGen
+	c.mu.sharedGen = gen
+	if !ok || v.gen <= seen {
+		return
+	}
+
+	switch {
+	case v.down && now.Before(v.retryAt):
+		if c.mu.breaker != BreakerOpen || c.mu.retryAt.Before(v.retryAt) {
+			c.setBreakerLocked(BreakerOpen)
+			c.mu.retryAt = v.retryAt
+		}
+	case !v.down && c.mu.breaker == BreakerOpen:
+		c.mu.retryAt = now
+	}
+}
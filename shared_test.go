@@ -0,0 +1,49 @@
+package lbsql
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSharedHealth(t *testing.T) {
+	now := time.Unix(100, 0)
+	shared := NewSharedHealth()
+	var dials []string
+	first := NewBalancer(WithCircuitBreaker(1, time.Minute), WithSharedHealth(shared))
+	second := NewBalancer(
+		WithCircuitBreaker(1, time.Minute),
+		WithSharedHealth(shared),
+		WithFaultInjector(func(name string) error {
+			dials = append(dials, name)
+			return nil
+		}),
+	)
+	for _, b := range []*Balancer{first, second} {
+		b.now = func() time.Time { return now }
+	}
+	first.Add("db", errConnector{})
+	second.Add("db", pingConnector{})
+	second.Add("other", pingConnector{})
+
+	first.Connect(context.Background())
+	if s, _ := second.BreakerState("db"); s != BreakerOpen {
+		t.Fatalf("expected the shared breaker to be %s; got %s", BreakerOpen, s)
+	}
+	for i := 0; i < 10; i++ {
+		if name := connectedName(t, second, context.Background()); name != "other" {
+			t.Fatalf("expected db to be ejected; got %s", name)
+		}
+	}
+	for _, name := range dials {
+		if name == "db" {
+			t.Fatal("expected db to not be dialed")
+		}
+	}
+
+	// Both balancers probe db again after the cooldown.
+	now = now.Add(time.Minute)
+	if s, _ := second.BreakerState("db"); s != BreakerHalfOpen {
+		t.Fatalf("expected %s after the cooldown; got %s", BreakerHalfOpen, s)
+	}
+}
@@ -0,0 +1,130 @@
+package lbsql
+
+import (
+	"time"
+)
+
+// deadlineUsageBounds are the bucket bounds for Stats.DeadlineUsage.
+var deadlineUsageBounds = []float64{0.1, 0.25, 0.5, 0.75, 0.9, 1}
+
+// holdTimeBounds are the bucket bounds in seconds for ConnectorInfo.HoldTime.
+var holdTimeBounds = []float64{0.01, 0.1, 1, 10, 60, 600, 3600}
+
+// queueWaitBounds are the bucket bounds in seconds for ConnectorInfo.QueueWait.
+var queueWaitBounds = []float64{0.001, 0.01, 0.1, 1, 10}
+
+// Histogram is a snapshot of observations counted in buckets.
+type Histogram struct {
+	// Bounds are the inclusive upper bounds of the buckets in increasing
+	// order.
+	Bounds []float64
+	// Counts is the number of observations in each bucket. It has one more
+	// entry than Bounds for observations above the last bound.
+	Counts []int64
+	// Count is the total number of observations.
+	Count int64
+	// Sum is the sum of all observations.
+	Sum float64
+}
+
+// histogram counts observations in buckets. It isn't safe for concurrent use.
+type histogram struct {
+	bounds []float64
+	counts []int64
+	count  int64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) histogram {
+	return histogram{
+		bounds: bounds,
+		counts: make([]int64, len(bounds)+1),
+	}
+}
+
+// observe adds v to the histogram.
+func (h *histogram) observe(v float64) {
+	i := 0
+	for i < len(h.bounds) && v > h.bounds[i] {
+		i++
+	}
+	h.counts[i]++
+	h.count++
+	h.sum += v
+}
+
+// snapshot returns a copy of the histogram.
+func (h *histogram) snapshot() Histogram {
+	return Histogram{
+		Bounds: append([]float64(nil), h.bounds...),
+		Counts: append([]int64(nil), h.counts...),
+		Count:  h.count,
+		Sum:    h.sum,
+	}
+}
+
+// Stats are counters for the balancer as a whole.
+type Stats struct {
+	// Connects is the number of Connect calls that succeeded.
+	Connects int64
+	// Failures is the number of Connect calls that failed.
+	Failures int64
+	// ConnectTime is the total time spent in Connect calls that succeeded,
+	// including failover.
+	ConnectTime time.Duration
+	// DeadlineUsage is the fraction of the context's remaining time that was
+	// used by each successful Connect call whose context had a deadline.
+	// Values close to 1 mean callers are close to timing out.
+	DeadlineUsage Histogram
+	// SLOViolations is the number of Connect calls, successful or not, that
+	// took longer than the SLO set by WithConnectSLO.
+	SLOViolations int64
+}
+
+// WithConnectSLO sets the connect latency objective. Connect calls and
+// individual connects to a connector that take longer are counted in
+// Stats.SLOViolations and ConnectorInfo.SLOViolations.
+func WithConnectSLO(d time.Duration) Option {
+	return func(b *Balancer) {
+		b.connectSLO = d
+	}
+}
+
+// Stats returns the balancer wide counters.
+func (b *Balancer) Stats() Stats {
+	b.stats.Lock()
+	defer b.stats.Unlock()
+
+	return Stats{
+		Connects:      b.stats.connects,
+		Failures:      b.stats.failures,
+		ConnectTime:   b.stats.connectTime,
+		DeadlineUsage: b.stats.deadlineUsage.snapshot(),
+		SLOViolations: b.stats.sloViolations,
+	}
+}
+
+// recordConnect records the outcome of a Connect call that started at start.
+func (b *Balancer) recordConnect(start, deadline time.Time, hasDeadline bool, err error) {
+	took := b.now().Sub(start)
+
+	b.stats.Lock()
+	defer b.stats.Unlock()
+
+	if b.connectSLO > 0 && took > b.connectSLO {
+		b.stats.sloViolations++
+	}
+	if err != nil {
+		b.stats.failures++
+		return
+	}
+	b.stats.connects++
+	b.stats.connectTime += took
+	if hasDeadline {
+		usage := 1.0
+		if available := deadline.Sub(start); available > 0 {
+			usage = float64(took) / float64(available)
+		}
+		b.stats.deadlineUsage.observe(usage)
+	}
+}
@@ -0,0 +1,120 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// sleepConnector connects after sleeping.
+type sleepConnector struct {
+	d time.Duration
+}
+
+func (c sleepConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	select {
+	case <-time.After(c.d):
+		return pingConn{}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (sleepConnector) Driver() driver.Driver { return nil }
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram([]float64{1, 2})
+	for _, v := range []float64{0.5, 1, 1.5, 3} {
+		h.observe(v)
+	}
+	s := h.snapshot()
+	if want := []int64{2, 1, 1}; !reflect.DeepEqual(s.Counts, want) {
+		t.Fatalf("expected counts %+v; got %+v", want, s.Counts)
+	}
+	if s.Count != 4 || s.Sum != 6 {
+		t.Fatalf("unexpected count and sum: %+v", s)
+	}
+}
+
+func TestStatsDeadlineUsage(t *testing.T) {
+	b := NewBalancer()
+	b.Add("slow", sleepConnector{d: 50 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := b.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Without a deadline there's no usage to record.
+	if _, err := b.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	b.Add("err", errConnector{})
+	b.Remove("slow")
+	b.Connect(context.Background())
+
+	stats := b.Stats()
+	if stats.Connects != 2 || stats.Failures != 1 {
+		t.Fatalf("unexpected connect counts: %+v", stats)
+	}
+	if stats.ConnectTime < 100*time.Millisecond {
+		t.Fatalf("expected at least 100ms of connect time; got %s", stats.ConnectTime)
+	}
+	usage := stats.DeadlineUsage
+	if usage.Count != 1 || usage.Sum < 0.5 || usage.Sum > 1 {
+		t.Fatalf("expected one deadline usage between 0.5 and 1; got %+v", usage)
+	}
+}
+
+func TestConnectSLO(t *testing.T) {
+	now := time.Unix(100, 0)
+	b := NewBalancer(WithStrategy(nameStrategy{}), WithConnectSLO(time.Second))
+	b.now = func() time.Time { return now }
+	b.Add("fast", pingConnector{})
+	b.Add("slow", connectorFunc(func(context.Context) (driver.Conn, error) {
+		now = now.Add(2 * time.Second)
+		return pingConn{}, nil
+	}))
+
+	for i := 0; i < 2; i++ {
+		if _, err := b.Connect(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.Remove("fast")
+	if _, err := b.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := b.Stats().SLOViolations; n != 1 {
+		t.Fatalf("expected 1 SLO violation; got %d", n)
+	}
+	if info := b.Describe()[0]; info.Name != "slow" || info.SLOViolations != 1 {
+		t.Fatalf("expected 1 SLO violation for slow; got %+v", info)
+	}
+}
+
+func TestHoldTime(t *testing.T) {
+	b := NewBalancer()
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	b.Add("a", pingConnector{})
+
+	c, err := b.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(5 * time.Second)
+	c.Close()
+	c.Close()
+
+	h := b.Describe()[0].HoldTime
+	if h.Count != 1 || h.Sum != 5 {
+		t.Fatalf("expected one 5s hold time; got %+v", h)
+	}
+	if want := []int64{0, 0, 0, 1, 0, 0, 0, 0}; !reflect.DeepEqual(h.Counts, want) {
+		t.Fatalf("expected counts %+v; got %+v", want, h.Counts)
+	}
+}
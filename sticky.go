@@ -0,0 +1,99 @@
+package lbsql
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+type stickyKey struct{}
+
+// WithStickyKey returns a context that makes StickyStrategy prefer the same
+// connector for every Connect call with the same key, such as a user or
+// session ID.
+func WithStickyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, stickyKey{}, key)
+}
+
+// stickyKeyFromContext returns the sticky key set by WithStickyKey.
+func stickyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(stickyKey{}).(string)
+	return key, ok
+}
+
+// StickyStrategy maps the key set with WithStickyKey to a preferred connector
+// using weighted rendezvous hashing. Connectors with a higher weight are
+// preferred by proportionally more keys. Because ejected connectors aren't
+// candidates, a key whose preferred connector is unhealthy moves to its next
+// preference and returns once the connector is healthy again, while other keys
+// keep their connector.
+type StickyStrategy struct {
+	// Fallback orders the connectors for Connect calls without a sticky key.
+	// It defaults to RandomStrategy.
+	Fallback Strategy
+}
+
+// Order sorts the candidates by their preference for the sticky key.
+func (s StickyStrategy) Order(ctx context.Context, candidates []ConnectorInfo) {
+	key, ok := stickyKeyFromContext(ctx)
+	if !ok {
+		fallback := s.Fallback
+		if fallback == nil {
+			fallback = RandomStrategy{}
+		}
+		fallback.Order(ctx, candidates)
+		return
+	}
+
+	scores := make(map[*connector]float64, len(candidates))
+	for _, c := range candidates {
+		scores[c.c] = rendezvousScore(key, c.Name, c.Weight)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return scores[candidates[i].c] > scores[candidates[j].c]
+	})
+}
+
+// rendezvousScore returns the weighted rendezvous hashing score of a connector
+// for a key. The connector with the highest score for a key is preferred.
+func rendezvousScore(key, name string, weight int) float64 {
+	if weight <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(name))
+	// Map the hash to a float in (0, 1).
+	u := (float64(h.Sum64()>>11) + 0.5) / (1 << 53)
+	return -float64(weight) / math.Log(u)
+}
+
+// WithBoundedLoadFactor bounds how many open connections the connector a sticky
+// key prefers can have, so hot keys don't overload it (consistent hashing with
+// bounded loads). A connector with more than factor times the average number
+// of open connections, counting the new one, is skipped and the key spills
+// over to its next preference. It only applies to Connect calls with a sticky
+// key, see WithStickyKey, and factor should be above 1.
+func WithBoundedLoadFactor(factor float64) Option {
+	return func(b *Balancer) {
+		b.loadFactor = factor
+	}
+}
+
+// boundLoad moves the candidates that have reached the load cap for factor
+// after the others, keeping the order otherwise.
+func boundLoad(candidates []ConnectorInfo, factor float64) {
+	if len(candidates) == 0 {
+		return
+	}
+	total := 1
+	for _, c := range candidates {
+		total += c.Open
+	}
+	limit := int(math.Ceil(factor * float64(total) / float64(len(candidates))))
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Open < limit && candidates[j].Open >= limit
+	})
+}
@@ -0,0 +1,95 @@
+package lbsql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func connectedName(t *testing.T, b *Balancer, ctx context.Context) string {
+	t.Helper()
+
+	c, err := b.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	return c.(*conn).c.name()
+}
+
+func TestStickyStrategy(t *testing.T) {
+	b := NewBalancer(WithStrategy(StickyStrategy{}))
+	for i := 0; i < 5; i++ {
+		b.Add(fmt.Sprintf("conn-%d", i), pingConnector{})
+	}
+
+	ctx := WithStickyKey(context.Background(), "user-1")
+	preferred := connectedName(t, b, ctx)
+	for i := 0; i < 20; i++ {
+		if name := connectedName(t, b, ctx); name != preferred {
+			t.Fatalf("expected sticky key to prefer %s; got %s", preferred, name)
+		}
+	}
+
+	// Eject the preferred connector and the key moves to another one.
+	c, _ := b.lookup(preferred)
+	c.mu.Lock()
+	c.mu.outlier = true
+	c.mu.Unlock()
+	fallback := connectedName(t, b, ctx)
+	if fallback == preferred {
+		t.Fatalf("expected key to move off the ejected connector")
+	}
+	for i := 0; i < 20; i++ {
+		if name := connectedName(t, b, ctx); name != fallback {
+			t.Fatalf("expected sticky key to prefer %s; got %s", fallback, name)
+		}
+	}
+
+	c.mu.Lock()
+	c.mu.outlier = false
+	c.mu.Unlock()
+	if name := connectedName(t, b, ctx); name != preferred {
+		t.Fatalf("expected key to return to %s; got %s", preferred, name)
+	}
+}
+
+func TestStickyStrategyWeights(t *testing.T) {
+	b := NewBalancer(WithStrategy(StickyStrategy{}))
+	b.Add("heavy", pingConnector{})
+	b.Add("light", pingConnector{})
+	b.SetWeight("heavy", 4)
+
+	picked := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		ctx := WithStickyKey(context.Background(), fmt.Sprintf("user-%d", i))
+		picked[connectedName(t, b, ctx)]++
+	}
+	if picked["heavy"] < 700 || picked["heavy"] > 900 {
+		t.Fatalf("expected heavy to be preferred by ~80%% of keys; got %+v", picked)
+	}
+}
+
+func TestBoundedLoadFactor(t *testing.T) {
+	b := NewBalancer(WithStrategy(StickyStrategy{}), WithBoundedLoadFactor(1.25))
+	for i := 0; i < 5; i++ {
+		b.Add(fmt.Sprint(i), pingConnector{})
+	}
+
+	// Every key prefers the same connector, so without the bound it would get
+	// every connection.
+	ctx := WithStickyKey(context.Background(), "hot")
+	const n = 100
+	for i := 0; i < n; i++ {
+		if _, err := b.Connect(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	limit := int(math.Ceil(1.25 * n / 5))
+	for _, info := range b.Describe() {
+		if info.Open > limit {
+			t.Fatalf("expected at most %d connections per connector; got %d for %s", limit, info.Open, info.Name)
+		}
+	}
+}
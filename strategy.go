@@ -0,0 +1,261 @@
+package lbsql
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Strategy decides the order connectors are attempted in by Connect. The first
+// connector is attempted first and the rest are used for failover.
+type Strategy interface {
+	// Order sorts the candidates in place.
+	Order(ctx context.Context, candidates []ConnectorInfo)
+}
+
+// Ranker is implemented by strategies that rank connectors from best to worst.
+// It is used by WithTopK.
+type Ranker interface {
+	// Less reports whether a is a better connector than b.
+	Less(a, b ConnectorInfo) bool
+}
+
+// RandomStrategy attempts the connectors in a random order.
+type RandomStrategy struct{}
+
+// Order shuffles the candidates.
+func (RandomStrategy) Order(ctx context.Context, candidates []ConnectorInfo) {
+	shuffle(randFromContext(ctx), candidates)
+}
+
+// WeightedStrategy orders the connectors by weighted random sampling without
+// replacement: each connector is picked next with a probability proportional
+// to its weight among the ones not picked yet, so heavier connectors are
+// preferred throughout failover and not just for the first attempt.
+// Connectors with a weight of zero are attempted last in a random order. The
+// weights are never summed, so any weight up to math.MaxInt can be used
+// without overflowing.
+type WeightedStrategy struct{}
+
+// Order sorts the candidates into a weighted random order.
+func (WeightedStrategy) Order(ctx context.Context, candidates []ConnectorInfo) {
+	r := randFromContext(ctx)
+	shuffle(r, candidates)
+
+	// Sorting by log(u)/weight for a uniform u in (0, 1] is equivalent to
+	// repeatedly making weighted picks (Efraimidis and Spirakis).
+	keys := make([]float64, len(candidates))
+	for i, c := range candidates {
+		if c.Weight <= 0 {
+			keys[i] = math.Inf(-1)
+			continue
+		}
+		keys[i] = math.Log(1-r.Float64()) / float64(c.Weight)
+	}
+	sort.Stable(weightedOrder{candidates: candidates, keys: keys})
+}
+
+// Less ranks connectors with a higher weight first.
+func (WeightedStrategy) Less(a, b ConnectorInfo) bool {
+	return a.Weight > b.Weight
+}
+
+// LatencyStrategy attempts the connectors with the lowest connect latency
+// first. Connectors that haven't connected yet are attempted before all others
+// so their latency can be measured.
+type LatencyStrategy struct{}
+
+// Order sorts the candidates by latency.
+func (s LatencyStrategy) Order(ctx context.Context, candidates []ConnectorInfo) {
+	shuffle(randFromContext(ctx), candidates)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return s.Less(candidates[i], candidates[j])
+	})
+}
+
+// Less ranks connectors with a lower latency first.
+func (LatencyStrategy) Less(a, b ConnectorInfo) bool {
+	return a.Latency < b.Latency
+}
+
+// LeastErrorsStrategy attempts the connectors with the fewest failed connects
+// within the error window first, see WithErrorWindow. Ties are broken at
+// random.
+type LeastErrorsStrategy struct{}
+
+// Order sorts the candidates by recent failures.
+func (s LeastErrorsStrategy) Order(ctx context.Context, candidates []ConnectorInfo) {
+	shuffle(randFromContext(ctx), candidates)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return s.Less(candidates[i], candidates[j])
+	})
+}
+
+// Less ranks connectors with fewer recent failures first.
+func (LeastErrorsStrategy) Less(a, b ConnectorInfo) bool {
+	return a.RecentFailures < b.RecentFailures
+}
+
+// DeterministicSpreadStrategy spreads connects evenly over the connectors
+// without using any randomness. The connectors are sorted by name and each
+// Connect starts one further along, so n calls over n connectors attempt each
+// of them first exactly once. Every tier and set retry pass of a Connect is
+// rotated by the same amount. It must be used as a pointer.
+type DeterministicSpreadStrategy struct {
+	next atomic.Uint64
+}
+
+// Order rotates the candidates, sorted by name, by the call count.
+func (s *DeterministicSpreadStrategy) Order(ctx context.Context, candidates []ConnectorInfo) {
+	if len(candidates) == 0 {
+		return
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Name < candidates[j].Name
+	})
+	n := int(s.cursor(ctx) % uint64(len(candidates)))
+	rotated := append(candidates[n:len(candidates):len(candidates)], candidates[:n]...)
+	copy(candidates, rotated)
+}
+
+type spreadCursorKey struct{}
+
+// spreadCursor is the position a DeterministicSpreadStrategy takes once per
+// Connect, so calls to Order for each tier and pass share it.
+type spreadCursor struct {
+	once sync.Once
+	n    uint64
+}
+
+// withSpreadCursor returns a context that makes DeterministicSpreadStrategy
+// advance once for all of the Connect calls with it.
+func withSpreadCursor(ctx context.Context) context.Context {
+	return context.WithValue(ctx, spreadCursorKey{}, &spreadCursor{})
+}
+
+// cursor returns the position to rotate the candidates by, advancing it once
+// per context from withSpreadCursor or on every call without one.
+func (s *DeterministicSpreadStrategy) cursor(ctx context.Context) uint64 {
+	c, ok := ctx.Value(spreadCursorKey{}).(*spreadCursor)
+	if !ok {
+		return s.next.Add(1) - 1
+	}
+	c.once.Do(func() {
+		c.n = s.next.Add(1) - 1
+	})
+	return c.n
+}
+
+// usesSpread reports whether s orders with a DeterministicSpreadStrategy.
+func usesSpread(s Strategy) bool {
+	switch s := s.(type) {
+	case *DeterministicSpreadStrategy:
+		return true
+	case StickyStrategy:
+		return usesSpread(s.Fallback)
+	case *StickyStrategy:
+		return usesSpread(s.Fallback)
+	default:
+		return false
+	}
+}
+
+// WithStableShuffle shuffles the connectors once when the set of connectors
+// changes and attempts them in that order on every Connect, instead of
+// ordering them with the strategy on each call. Each process gets its own
+// order, but the order is predictable within a process.
+func WithStableShuffle(stable bool) Option {
+	return func(b *Balancer) {
+		b.stableShuffle = stable
+	}
+}
+
+// WithFirstAvailable makes Connect skip the strategy and exploration and
+// attempt the connectors within each tier starting with the one that most
+// recently connected successfully, then by name, returning the first
+// connection that succeeds. Tiers, roles, WithPreferred and connector hints
+// still apply, and connectors that recently failed or are being avoided are
+// still attempted last. This trades balancing for the least work per Connect.
+func WithFirstAvailable(first bool) Option {
+	return func(b *Balancer) {
+		b.firstAvailable = first
+	}
+}
+
+// sortBySuccess sorts the candidates by their last successful connect, most
+// recent first, and then by name.
+func sortBySuccess(candidates []ConnectorInfo) {
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if !a.LastSuccess.Equal(b.LastSuccess) {
+			return a.LastSuccess.After(b.LastSuccess)
+		}
+		return a.Name < b.Name
+	})
+}
+
+// WithPreferred makes Connect always attempt the named connector first while it
+// is healthy. The other connectors are ordered by the strategy and used for
+// failover.
+func WithPreferred(name string) Option {
+	return func(b *Balancer) {
+		b.preferred = name
+	}
+}
+
+// preferFirst moves the named candidate to the front, keeping the order of the
+// rest.
+func preferFirst(candidates []ConnectorInfo, name string) {
+	for i, c := range candidates {
+		if c.Name == name {
+			copy(candidates[1:i+1], candidates[:i])
+			candidates[0] = c
+			return
+		}
+	}
+}
+
+// order sorts candidates from a single tier with the strategy.
+func (b *Balancer) order(ctx context.Context, candidates []ConnectorInfo) {
+	if !b.stableShuffle {
+		b.strategy.Order(ctx, candidates)
+	}
+	if r, ok := b.strategy.(Ranker); ok && b.topK > 0 {
+		topK(randFromContext(ctx), candidates, r, b.topK)
+	}
+	if _, ok := stickyKeyFromContext(ctx); ok && b.loadFactor > 0 {
+		boundLoad(candidates, b.loadFactor)
+	}
+}
+
+// topK sorts the candidates by rank and shuffles the best k.
+func topK(rnd randSource, candidates []ConnectorInfo, r Ranker, k int) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return r.Less(candidates[i], candidates[j])
+	})
+	if k > len(candidates) {
+		k = len(candidates)
+	}
+	shuffle(rnd, candidates[:k])
+}
+
+// weightedOrder sorts candidates by their keys, largest first.
+type weightedOrder struct {
+	candidates []ConnectorInfo
+	keys       []float64
+}
+
+func (o weightedOrder) Len() int           { return len(o.candidates) }
+func (o weightedOrder) Less(i, j int) bool { return o.keys[i] > o.keys[j] }
+func (o weightedOrder) Swap(i, j int) {
+	o.candidates[i], o.candidates[j] = o.candidates[j], o.candidates[i]
+	o.keys[i], o.keys[j] = o.keys[j], o.keys[i]
+}
+
+func shuffle(r randSource, candidates []ConnectorInfo) {
+	r.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+}
@@ -0,0 +1,334 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestLatencyStrategyTopK(t *testing.T) {
+	b := NewBalancer(WithStrategy(LatencyStrategy{}), WithTopK(2))
+	latencies := map[string]time.Duration{
+		"a": 1 * time.Millisecond,
+		"b": 2 * time.Millisecond,
+		"c": 3 * time.Millisecond,
+		"d": 4 * time.Millisecond,
+	}
+	for name, latency := range latencies {
+		b.Add(name, testConnector{})
+		b.mu.connectors[name].mu.latency = latency
+	}
+
+	picked := map[string]int{}
+	for i := 0; i < 200; i++ {
+		candidates, err := b.candidates(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		picked[candidates[0].Name]++
+		if len(candidates) != len(latencies) {
+			t.Fatalf("expected all connectors as candidates; got %d", len(candidates))
+		}
+	}
+	if len(picked) != 2 || picked["a"] == 0 || picked["b"] == 0 {
+		t.Fatalf("expected only a and b to be picked; got %+v", picked)
+	}
+}
+
+func TestWeightedStrategy(t *testing.T) {
+	b := NewBalancer(WithStrategy(WeightedStrategy{}))
+	b.Add("heavy", testConnector{})
+	b.Add("light", testConnector{})
+	b.Add("none", testConnector{})
+	b.SetWeight("heavy", 9)
+	b.SetWeight("none", 0)
+
+	picked := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		candidates, err := b.candidates(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		picked[candidates[0].Name]++
+	}
+	if picked["none"] != 0 {
+		t.Fatalf("expected zero weight connector to never be picked first; got %+v", picked)
+	}
+	if picked["heavy"] < 800 {
+		t.Fatalf("expected heavy connector to be picked ~90%% of the time; got %+v", picked)
+	}
+}
+
+func TestWeightedStrategyLargeWeights(t *testing.T) {
+	cases := []struct {
+		name    string
+		weights map[string]int
+		// want is the expected fraction of picks for "a".
+		want float64
+	}{
+		{"int32", map[string]int{"a": math.MaxInt32, "b": math.MaxInt32 / 3}, 0.75},
+		{"overflowing sum", map[string]int{"a": math.MaxInt/2 + 1, "b": math.MaxInt/2 + 1}, 0.5},
+		{"max", map[string]int{"a": math.MaxInt, "b": math.MaxInt}, 0.5},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewBalancer(WithStrategy(WeightedStrategy{}))
+			for name, w := range tc.weights {
+				b.Add(name, testConnector{})
+				b.SetWeight(name, w)
+			}
+
+			const n = 4000
+			picked := 0
+			for i := 0; i < n; i++ {
+				if candidateNames(t, b)[0] == "a" {
+					picked++
+				}
+			}
+			if got := float64(picked) / n; math.Abs(got-tc.want) > 0.05 {
+				t.Fatalf("expected a to be picked %.2f of the time; got %.2f", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestWeightedStrategyTopK(t *testing.T) {
+	b := NewBalancer(WithStrategy(WeightedStrategy{}), WithTopK(1))
+	b.Add("heavy", testConnector{})
+	b.Add("light", testConnector{})
+	b.SetWeight("heavy", 2)
+
+	for i := 0; i < 100; i++ {
+		candidates, err := b.candidates(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name := candidates[0].Name; name != "heavy" {
+			t.Fatalf("expected heavy to be picked; got %q", name)
+		}
+	}
+}
+
+// nameStrategy attempts connectors in order of their names.
+type nameStrategy struct{}
+
+func (nameStrategy) Order(_ context.Context, candidates []ConnectorInfo) {
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Name < candidates[j].Name
+	})
+}
+
+func candidateNames(t *testing.T, b *Balancer) []string {
+	t.Helper()
+
+	candidates, err := b.candidates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range candidates {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestStableShuffle(t *testing.T) {
+	b := NewBalancer(WithStableShuffle(true))
+	for i := 0; i < 10; i++ {
+		b.AddAuto(testConnector{})
+	}
+
+	order := candidateNames(t, b)
+	for i := 0; i < 20; i++ {
+		if got := candidateNames(t, b); !reflect.DeepEqual(got, order) {
+			t.Fatalf("expected stable order %+v; got %+v", order, got)
+		}
+	}
+
+	// Changing the set reshuffles. With 11 connectors a repeat of the same
+	// relative order is unlikely, so retry a few times to avoid flakes.
+	changed := false
+	for i := 0; i < 5 && !changed; i++ {
+		name := b.AddAuto(testConnector{})
+		b.Remove(name)
+		changed = !reflect.DeepEqual(candidateNames(t, b), order)
+	}
+	if !changed {
+		t.Fatalf("expected order to change when the set changes")
+	}
+}
+
+func TestPreferred(t *testing.T) {
+	b := NewBalancer(WithPreferred("local"), WithCircuitBreaker(1, time.Minute))
+	b.Add("local", pingConnector{})
+	for i := 0; i < 5; i++ {
+		b.AddAuto(pingConnector{})
+	}
+
+	for i := 0; i < 20; i++ {
+		if names := candidateNames(t, b); names[0] != "local" || len(names) != 6 {
+			t.Fatalf("expected local to be attempted first; got %+v", names)
+		}
+	}
+
+	// Once ejected the preferred connector is skipped.
+	c, _ := b.lookup("local")
+	c.Connector = errConnector{}
+	b.connect(context.Background(), c)
+	for i := 0; i < 20; i++ {
+		for _, name := range candidateNames(t, b) {
+			if name == "local" {
+				t.Fatalf("expected ejected preferred connector to be skipped")
+			}
+		}
+	}
+}
+
+func TestLeastErrorsStrategy(t *testing.T) {
+	b := NewBalancer(WithStrategy(LeastErrorsStrategy{}))
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	failures := map[string]int{"a": 3, "b": 0, "c": 1, "d": 0}
+	for name, n := range failures {
+		b.Add(name, testConnector{})
+		c := b.mu.connectors[name]
+		for i := 0; i < n; i++ {
+			b.recordFailureLocked(c, errors.New("err"))
+		}
+	}
+
+	picked := map[string]int{}
+	for i := 0; i < 100; i++ {
+		candidates, err := b.candidates(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		picked[candidates[0].Name]++
+		if last := candidates[len(candidates)-1].Name; last != "a" {
+			t.Fatalf("expected a to be attempted last; got %s", last)
+		}
+	}
+	if len(picked) != 2 || picked["b"] == 0 || picked["d"] == 0 {
+		t.Fatalf("expected ties between b and d to be broken at random; got %+v", picked)
+	}
+
+	// Failures outside of the window are forgotten.
+	now = now.Add(2 * defaultErrorWindow)
+	if n := b.Describe()[0].RecentFailures; n != 0 {
+		t.Fatalf("expected failures to leave the window; got %d", n)
+	}
+}
+
+func TestDeterministicSpreadStrategy(t *testing.T) {
+	run := func() []string {
+		b := NewBalancer(WithStrategy(&DeterministicSpreadStrategy{}))
+		for _, name := range []string{"c", "a", "b"} {
+			b.Add(name, pingConnector{})
+		}
+		var picked []string
+		for i := 0; i < 300; i++ {
+			picked = append(picked, connectedName(t, b, context.Background()))
+		}
+		return picked
+	}
+
+	picked := run()
+	counts := map[string]int{}
+	for _, name := range picked {
+		counts[name]++
+	}
+	if want := map[string]int{"a": 100, "b": 100, "c": 100}; !reflect.DeepEqual(counts, want) {
+		t.Fatalf("expected an exactly even spread %+v; got %+v", want, counts)
+	}
+	if !reflect.DeepEqual(picked[:3], []string{"a", "b", "c"}) {
+		t.Fatalf("expected connectors to be picked in name order; got %+v", picked[:3])
+	}
+	if again := run(); !reflect.DeepEqual(picked, again) {
+		t.Fatal("expected the same sequence on every run")
+	}
+}
+
+func TestDeterministicSpreadStrategyTiers(t *testing.T) {
+	b := NewBalancer(WithStrategy(&DeterministicSpreadStrategy{}))
+	b.AddTiered("a", pingConnector{}, 0, 1)
+	b.AddTiered("b", pingConnector{}, 0, 1)
+	b.AddTiered("c", pingConnector{}, 1, 1)
+
+	// Each Connect advances the rotation once, not once per tier.
+	counts := map[string]int{}
+	for i := 0; i < 10; i++ {
+		counts[connectedName(t, b, context.Background())]++
+	}
+	if want := map[string]int{"a": 5, "b": 5}; !reflect.DeepEqual(counts, want) {
+		t.Fatalf("expected an even spread over tier 0 %+v; got %+v", want, counts)
+	}
+}
+
+// countStrategy counts how often it's asked to order the candidates.
+type countStrategy struct {
+	calls *int
+}
+
+func (s countStrategy) Order(context.Context, []ConnectorInfo) { *s.calls++ }
+
+func TestFirstAvailable(t *testing.T) {
+	var calls int
+	b := NewBalancer(
+		WithStrategy(countStrategy{calls: &calls}),
+		WithFirstAvailable(true),
+	)
+	now := time.Unix(100, 0)
+	b.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	b.AddTiered("a", pingConnector{}, 1, 1)
+	b.AddTiered("b", pingConnector{}, 0, 1)
+	b.AddTiered("c", pingConnector{}, 0, 1)
+	b.AddTiered("d", pingConnector{}, 0, 1)
+	b.AddRole("e", pingConnector{}, ReadOnly)
+
+	// The read-only connector is preferred for reads but not for writes.
+	if name := connectedName(t, b, context.Background()); name != "e" {
+		t.Fatalf("expected e for reads; got %s", name)
+	}
+	write := WithWriteIntent(context.Background(), true)
+	if name := connectedName(t, b, write); name != "b" {
+		t.Fatalf("expected b, the first of tier 0 by name; got %s", name)
+	}
+	// The last connector to succeed stays first.
+	connectedName(t, b, WithConnectorHint(write, "d"))
+	for i := 0; i < 10; i++ {
+		if name := connectedName(t, b, write); name != "d" {
+			t.Fatalf("expected d which last succeeded; got %s", name)
+		}
+	}
+	if calls != 0 {
+		t.Fatalf("expected the strategy to be skipped; got %d calls", calls)
+	}
+}
+
+func TestWeightedStrategyFailoverOrder(t *testing.T) {
+	b := NewBalancer(WithStrategy(WeightedStrategy{}))
+	for name, weight := range map[string]int{"a": 1, "b": 10, "c": 100, "d": 0} {
+		b.Add(name, testConnector{})
+		b.SetWeight(name, weight)
+	}
+
+	// b should usually be attempted second, after c.
+	second := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		names := candidateNames(t, b)
+		second[names[1]]++
+		if names[3] != "d" {
+			t.Fatalf("expected the zero weight connector last; got %+v", names)
+		}
+	}
+	if second["b"] < 750 {
+		t.Fatalf("expected the weights to apply to failover; got %+v", second)
+	}
+}
@@ -0,0 +1,45 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+type subsetKey struct{}
+
+// Subset returns a driver.Connector that balances over the connectors whose
+// names match pred, for example the connectors in one zone. It shares the
+// connectors and all of their state with the balancer, so health, stats and
+// later changes to the set of connectors apply to both. If no connectors
+// match, Connect fails with ErrNoConnectors.
+func (b *Balancer) Subset(pred func(name string) bool) driver.Connector {
+	return subsetConnector{b: b, pred: pred}
+}
+
+type subsetConnector struct {
+	b    *Balancer
+	pred func(name string) bool
+}
+
+// Connect connects to one of the matching connectors.
+func (s subsetConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	pred := s.pred
+	if parent := subsetFromContext(ctx); parent != nil {
+		pred = func(name string) bool {
+			return parent(name) && s.pred(name)
+		}
+	}
+	return s.b.Connect(context.WithValue(ctx, subsetKey{}, pred))
+}
+
+// Driver returns the balancer.
+func (s subsetConnector) Driver() driver.Driver {
+	return s.b
+}
+
+// subsetFromContext returns the predicate of the subset Connect was called
+// through, if any.
+func subsetFromContext(ctx context.Context) func(name string) bool {
+	pred, _ := ctx.Value(subsetKey{}).(func(name string) bool)
+	return pred
+}
@@ -0,0 +1,36 @@
+package lbsql
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSubset(t *testing.T) {
+	b := NewBalancer()
+	for _, name := range []string{"us-1", "us-2", "eu-1", "eu-2"} {
+		b.Add(name, pingConnector{})
+	}
+	us := b.Subset(func(name string) bool { return strings.HasPrefix(name, "us-") })
+
+	for i := 0; i < 50; i++ {
+		c, err := us.Connect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name := c.(ConnNamer).ConnectorName(); !strings.HasPrefix(name, "us-") {
+			t.Fatalf("expected a us connector; got %s", name)
+		}
+		c.Close()
+	}
+
+	// The subset shares the balancer's state.
+	if n := b.Stats().Connects; n != 50 {
+		t.Fatalf("expected the balancer to count 50 connects; got %d", n)
+	}
+
+	none := b.Subset(func(string) bool { return false })
+	if _, err := none.Connect(context.Background()); err != ErrNoConnectors {
+		t.Fatalf("expected %+v; got %+v", ErrNoConnectors, err)
+	}
+}
@@ -0,0 +1,82 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"sort"
+)
+
+// AddTagged adds a driver.Connector to the balancer with a set of tags, such
+// as its zone, role or shard. Connect calls with a context from WithSelector
+// only use the connectors whose tags match, ConnectorsByTag finds connectors
+// by tag and MetricsByTagKey aggregates their metrics by tag.
+func (b *Balancer) AddTagged(name string, c driver.Connector, tags map[string]string) {
+	copied := make(map[string]string, len(tags))
+	for k, v := range tags {
+		copied[k] = v
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.checkAddLocked(name, c) != nil {
+		return
+	}
+	// The tags are set before the connector is published so selectors
+	// always see them.
+	conn := b.insertLocked(name, c)
+	conn.mu.Lock()
+	conn.mu.tags = copied
+	conn.mu.Unlock()
+	b.publishLocked()
+}
+
+// ConnectorsByTag returns the sorted names of the connectors with the tag key
+// set to value.
+func (b *Balancer) ConnectorsByTag(key, value string) []string {
+	var names []string
+	for _, c := range *b.connectors.Load() {
+		c.mu.Lock()
+		if v, ok := c.mu.tags[key]; ok && v == value {
+			names = append(names, c.mu.name)
+		}
+		c.mu.Unlock()
+	}
+	sort.Strings(names)
+	return names
+}
+
+type selectorKey struct{}
+
+// WithSelector returns a context that makes Connect only use the connectors
+// that have every tag in selector with the same value, see AddTagged. If no
+// connectors match, Connect fails with ErrNoConnectors. Selectors set on
+// parent contexts must match too.
+func WithSelector(ctx context.Context, selector map[string]string) context.Context {
+	copied := make(map[string]string, len(selector))
+	for k, v := range selector {
+		copied[k] = v
+	}
+	parent := selectorFromContext(ctx)
+	selectors := make([]map[string]string, 0, len(parent)+1)
+	selectors = append(append(selectors, parent...), copied)
+	return context.WithValue(ctx, selectorKey{}, selectors)
+}
+
+// selectorFromContext returns the selectors set by WithSelector, if any.
+func selectorFromContext(ctx context.Context) []map[string]string {
+	selectors, _ := ctx.Value(selectorKey{}).([]map[string]string)
+	return selectors
+}
+
+// matchesTags reports whether tags match all of the selectors.
+func matchesTags(tags map[string]string, selectors []map[string]string) bool {
+	for _, selector := range selectors {
+		for k, v := range selector {
+			if tag, ok := tags[k]; !ok || tag != v {
+				return false
+			}
+		}
+	}
+	return true
+}
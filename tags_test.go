@@ -0,0 +1,46 @@
+package lbsql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	b := NewBalancer()
+	b.AddTagged("us-1", pingConnector{}, map[string]string{"zone": "us", "role": "primary"})
+	b.AddTagged("us-2", pingConnector{}, map[string]string{"zone": "us", "role": "replica"})
+	b.AddTagged("eu-1", errConnector{}, map[string]string{"zone": "eu", "role": "replica"})
+	b.Add("untagged", pingConnector{})
+
+	if names := b.ConnectorsByTag("zone", "us"); !reflect.DeepEqual(names, []string{"us-1", "us-2"}) {
+		t.Fatalf("expected the us connectors; got %+v", names)
+	}
+	if names := b.ConnectorsByTag("zone", "ap"); len(names) != 0 {
+		t.Fatalf("expected no connectors; got %+v", names)
+	}
+
+	us := WithSelector(context.Background(), map[string]string{"zone": "us"})
+	for i := 0; i < 20; i++ {
+		if name := connectedName(t, b, us); name != "us-1" && name != "us-2" {
+			t.Fatalf("expected a us connector; got %s", name)
+		}
+	}
+	replica := WithSelector(us, map[string]string{"role": "replica"})
+	for i := 0; i < 10; i++ {
+		if name := connectedName(t, b, replica); name != "us-2" {
+			t.Fatalf("expected us-2; got %s", name)
+		}
+	}
+	ap := WithSelector(context.Background(), map[string]string{"zone": "ap"})
+	if _, err := b.Connect(ap); err != ErrNoConnectors {
+		t.Fatalf("expected %+v; got %+v", ErrNoConnectors, err)
+	}
+
+	b.Connect(WithSelector(context.Background(), map[string]string{"zone": "eu"}))
+	metrics := b.MetricsByTagKey("zone")
+	if len(metrics) != 2 || metrics["us"].Connectors != 2 || metrics["us"].Connects != 30 ||
+		metrics["eu"].Failures != 1 {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+}
@@ -0,0 +1,109 @@
+package lbsql
+
+import (
+	"database/sql/driver"
+	"sort"
+)
+
+// AddTiered adds a driver.Connector to the balancer in the given tier with the
+// given weight. Connect attempts every connector in a lower tier before any in
+// a higher tier, so higher tiers are only used once every connector in the
+// lower tiers has failed or been ejected. Within a tier connectors are ordered
+// by the strategy, so with WeightedStrategy the weights apply within the tier.
+// Connectors added with Add are in tier 0. Like Add it does nothing if the
+// connector is rejected.
+func (b *Balancer) AddTiered(name string, c driver.Connector, tier, weight int) {
+	if weight < 0 {
+		weight = 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.checkAddLocked(name, c) != nil {
+		return
+	}
+	// The tier and weight are set before the connector is published so
+	// Connect never sees a standby as part of tier 0.
+	conn := b.insertLocked(name, c)
+	conn.mu.Lock()
+	conn.mu.tier = tier
+	conn.mu.weight = weight
+	conn.mu.reportedWeight = weight
+	conn.mu.Unlock()
+	if tier != 0 {
+		b.ordered.Store(true)
+	}
+	b.publishLocked()
+}
+
+// WithExploration makes Connect attempt a random connector from a higher tier
+// first with probability prob, even while the lower tiers are healthy. This
+// keeps standbys warm and finds out they're broken before a real failover
+// does. Failover still follows the tiers.
+func WithExploration(prob float64) Option {
+	return func(b *Balancer) {
+		b.exploration = prob
+	}
+}
+
+// explore moves a random candidate from outside of the first tier to the
+// front with the exploration probability. The candidates must be sorted by
+// tier.
+func (b *Balancer) explore(candidates []ConnectorInfo) {
+	if b.exploration <= 0 || len(candidates) == 0 {
+		return
+	}
+	first := 1
+	for first < len(candidates) && candidates[first].Tier == candidates[0].Tier {
+		first++
+	}
+	if first == len(candidates) || b.rand.Float64() >= b.exploration {
+		return
+	}
+	i := first + b.rand.Intn(len(candidates)-first)
+	preferFirst(candidates, candidates[i].Name)
+}
+
+// sortByTier sorts the candidates by tier, keeping their order within a tier.
+func sortByTier(candidates []ConnectorInfo) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Tier < candidates[j].Tier
+	})
+}
+
+// forEachTier calls fn with each run of candidates in the same tier. The
+// candidates must be sorted by tier.
+func forEachTier(candidates []ConnectorInfo, fn func(tier []ConnectorInfo)) {
+	for len(candidates) > 0 {
+		n := 1
+		for n < len(candidates) && candidates[n].Tier == candidates[0].Tier {
+			n++
+		}
+		fn(candidates[:n])
+		candidates = candidates[n:]
+	}
+}
+
+// WithFailoverWithinTier makes Connect only fail over to connectors in the
+// same tier as the first one it attempts, failing instead of moving on to
+// another tier once that tier is exhausted. This keeps workloads such as
+// writes from falling back to a distant tier.
+func WithFailoverWithinTier(within bool) Option {
+	return func(b *Balancer) {
+		b.failoverWithinTier = within
+	}
+}
+
+// sameTier returns the prefix of the candidates in the same tier as the first,
+// keeping the order.
+func sameTier(candidates []ConnectorInfo) []ConnectorInfo {
+	n := 0
+	for _, c := range candidates {
+		if c.Tier == candidates[0].Tier {
+			candidates[n] = c
+			n++
+		}
+	}
+	return candidates[:n]
+}
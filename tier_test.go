@@ -0,0 +1,110 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAddTiered(t *testing.T) {
+	b := NewBalancer(WithStrategy(WeightedStrategy{}))
+	b.AddTiered("primary", pingConnector{}, 0, 3)
+	b.AddTiered("standby", pingConnector{}, 0, 1)
+	b.AddTiered("replica1", pingConnector{}, 1, 10)
+	b.AddTiered("replica2", pingConnector{}, 1, 10)
+
+	picked := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		names := candidateNames(t, b)
+		picked[names[0]]++
+		if names[2] != "replica1" && names[2] != "replica2" {
+			t.Fatalf("expected tier 1 after all of tier 0; got %+v", names)
+		}
+	}
+	if picked["replica1"]+picked["replica2"] != 0 {
+		t.Fatalf("expected tier 1 to never be picked first; got %+v", picked)
+	}
+	if picked["primary"] < 650 || picked["primary"] > 850 {
+		t.Fatalf("expected primary to be picked ~75%% of the time; got %+v", picked)
+	}
+
+	// Tier 1 is only reached once tier 0 fails.
+	for _, name := range []string{"primary", "standby"} {
+		c, _ := b.lookup(name)
+		c.Connector = errConnector{}
+	}
+	if name := connectedName(t, b, context.Background()); name != "replica1" && name != "replica2" {
+		t.Fatalf("expected failover to tier 1; got %s", name)
+	}
+	for _, info := range b.Describe() {
+		if info.Tier == 0 && info.Failures != 1 {
+			t.Fatalf("expected %s to be attempted once; got %+v", info.Name, info)
+		}
+	}
+}
+
+func TestExploration(t *testing.T) {
+	b := NewBalancer(WithExploration(0.5))
+	b.AddTiered("primary", pingConnector{}, 0, 1)
+	b.AddTiered("standby1", pingConnector{}, 1, 1)
+	b.AddTiered("standby2", pingConnector{}, 2, 1)
+
+	picked := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		names := candidateNames(t, b)
+		picked[names[0]]++
+		if names[0] != "primary" && names[1] != "primary" {
+			t.Fatalf("expected failover to go back to the tiers; got %+v", names)
+		}
+	}
+	if picked["primary"] < 400 || picked["primary"] > 600 {
+		t.Fatalf("expected primary to be picked ~50%% of the time; got %+v", picked)
+	}
+	if picked["standby1"] == 0 || picked["standby2"] == 0 {
+		t.Fatalf("expected every standby to be explored; got %+v", picked)
+	}
+}
+
+func TestFailoverWithinTier(t *testing.T) {
+	b := NewBalancer(WithFailoverWithinTier(true))
+	b.AddTiered("primary-1", failConnector{errors.New("down")}, 0, 1)
+	b.AddTiered("primary-2", failConnector{errors.New("down")}, 0, 1)
+	dials := 0
+	b.AddTiered("replica", connectorFunc(func(context.Context) (driver.Conn, error) {
+		dials++
+		return pingConn{}, nil
+	}), 1, 1)
+
+	_, err := b.Connect(context.Background())
+	var connErr *ConnectError
+	if !errors.As(err, &connErr) || len(connErr.Errors) != 2 {
+		t.Fatalf("expected both primaries to fail; got %+v", err)
+	}
+	if dials != 0 {
+		t.Fatalf("expected the replica tier to not be touched; got %d dials", dials)
+	}
+}
+
+func TestFailoverWithinTierFailureCache(t *testing.T) {
+	b := NewBalancer(WithFailoverWithinTier(true), WithFailureCache(time.Minute))
+	fail := true
+	b.AddTiered("primary", connectorFunc(func(context.Context) (driver.Conn, error) {
+		if fail {
+			return nil, errors.New("blip")
+		}
+		return pingConn{}, nil
+	}), 0, 1)
+	b.AddTiered("replica", pingConnector{}, 1, 1)
+
+	if _, err := b.Connect(context.Background()); err == nil {
+		t.Fatal("expected the primary to fail")
+	}
+	// The recently failed primary is still the only candidate instead of the
+	// replica being moved ahead of it.
+	fail = false
+	if name := connectedName(t, b, context.Background()); name != "primary" {
+		t.Fatalf("expected primary; got %s", name)
+	}
+}
@@ -0,0 +1,169 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// ErrDeadlineTooSoon is recorded for connectors skipped by
+// WithPredictiveDeadline.
+var ErrDeadlineTooSoon = errors.New("lbsql: not enough time left before the deadline")
+
+// WithDialTimeout bounds how long Connect waits on a single connector before
+// failing over to the next one.
+func WithDialTimeout(d time.Duration) Option {
+	return func(b *Balancer) {
+		b.dialTimeout = d
+	}
+}
+
+// WithTotalTimeout bounds how long Connect spends across all of its attempts,
+// including failover and set retries. Like the dial timeout it only shortens
+// the caller's deadline, never extends it.
+func WithTotalTimeout(d time.Duration) Option {
+	return func(b *Balancer) {
+		b.totalTimeout = d
+	}
+}
+
+// SetDialTimeout overrides the dial timeout for the named connector, so a
+// slow remote backend can be given longer than a local one. Zero returns it
+// to the timeout set by WithDialTimeout.
+func (b *Balancer) SetDialTimeout(name string, d time.Duration) {
+	c, ok := b.lookup(name)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mu.dialTimeout = d
+}
+
+// WithPredictiveDeadline makes Connect skip connectors whose connect latency,
+// see ConnectorInfo.Latency, is longer than the time left before the context's
+// deadline, instead of starting a dial that's unlikely to finish in time.
+// Connectors that haven't connected yet are always attempted.
+func WithPredictiveDeadline(predict bool) Option {
+	return func(b *Balancer) {
+		b.predictiveDeadline = predict
+	}
+}
+
+// fitsDeadline reports whether connecting to c is expected to finish before
+// the context's deadline.
+func (b *Balancer) fitsDeadline(ctx context.Context, c *connector) bool {
+	deadline, ok := ctx.Deadline()
+	if !b.predictiveDeadline || !ok {
+		return true
+	}
+	c.mu.Lock()
+	latency := c.mu.latency
+	c.mu.Unlock()
+	return latency < deadline.Sub(b.now())
+}
+
+// WithAdaptiveAttemptTimeout bounds each attempt by multiplier times the
+// connector's connect latency, see ConnectorInfo.Latency, clamped between min
+// and max, so fast backends fail fast while slower ones get longer. Connectors
+// without a latency yet get max. It replaces WithDialTimeout, while timeouts
+// set by SetDialTimeout still take precedence.
+func WithAdaptiveAttemptTimeout(multiplier float64, min, max time.Duration) Option {
+	return func(b *Balancer) {
+		b.adaptiveTimeout.multiplier = multiplier
+		b.adaptiveTimeout.min = min
+		b.adaptiveTimeout.max = max
+	}
+}
+
+// attemptTimeout returns the timeout for an attempt of c, or zero if there
+// isn't one.
+func (b *Balancer) attemptTimeout(c *connector) time.Duration {
+	c.mu.Lock()
+	timeout := c.mu.dialTimeout
+	latency := c.mu.latency
+	c.mu.Unlock()
+	if timeout > 0 {
+		return timeout
+	}
+
+	adaptive := b.adaptiveTimeout
+	if adaptive.multiplier <= 0 {
+		return b.dialTimeout
+	}
+	if latency <= 0 {
+		return adaptive.max
+	}
+	timeout = time.Duration(adaptive.multiplier * float64(latency))
+	if timeout < adaptive.min {
+		timeout = adaptive.min
+	}
+	if adaptive.max > 0 && timeout > adaptive.max {
+		timeout = adaptive.max
+	}
+	return timeout
+}
+
+// attempt connects to c, bounded by its attempt timeout.
+func (b *Balancer) attempt(ctx context.Context, c *connector) (driver.Conn, error) {
+	if timeout := b.attemptTimeout(c); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return b.connect(ctx, c)
+}
+
+// WithEnforcedTimeouts makes Connect stop waiting on a connector once the
+// attempt's context is done even if the connector doesn't return, so a
+// connector that ignores cancellation can't hang Connect. The abandoned dial
+// keeps running in the background, and the connection is closed if it ever
+// returns one. Each dial with a timeout or cancellable context then runs in
+// its own goroutine.
+func WithEnforcedTimeouts(enforce bool) Option {
+	return func(b *Balancer) {
+		b.enforceTimeouts = enforce
+	}
+}
+
+// enforcedConnect connects to c, giving up once the context is done if
+// WithEnforcedTimeouts is set.
+func (b *Balancer) enforcedConnect(ctx context.Context, c *connector) (driver.Conn, error) {
+	if !b.enforceTimeouts || ctx.Done() == nil {
+		return c.Connect(ctx)
+	}
+
+	type result struct {
+		conn driver.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := c.Connect(ctx)
+		done <- result{conn: conn, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.conn, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// ConnectWithTimeout is like Connect but gives up after d, or earlier if the
+// context's deadline comes first.
+func (b *Balancer) ConnectWithTimeout(ctx context.Context, d time.Duration) (driver.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	return b.Connect(ctx)
+}
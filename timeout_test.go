@@ -0,0 +1,255 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+// slowConnector blocks until the context is done.
+type slowConnector struct{}
+
+func (slowConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (slowConnector) Driver() driver.Driver { return nil }
+
+func TestDialTimeout(t *testing.T) {
+	b := NewBalancer(
+		WithStrategy(nameStrategy{}),
+		WithDialTimeout(10*time.Millisecond),
+		WithTotalTimeout(time.Second),
+	)
+	b.Add("a", slowConnector{})
+	b.Add("b", slowConnector{})
+	b.Add("c", slowConnector{})
+	b.Add("d", pingConnector{})
+
+	start := time.Now()
+	if _, err := b.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took >= time.Second {
+		t.Fatalf("expected failover within the total timeout; took %s", took)
+	}
+	for _, info := range b.Describe()[:3] {
+		if info.Failures != 1 {
+			t.Fatalf("expected %s to be attempted once; got %+v", info.Name, info)
+		}
+	}
+}
+
+func TestTotalTimeout(t *testing.T) {
+	b := NewBalancer(
+		WithStrategy(nameStrategy{}),
+		WithDialTimeout(time.Second),
+		WithTotalTimeout(20*time.Millisecond),
+	)
+	b.Add("a", slowConnector{})
+	b.Add("b", pingConnector{})
+
+	if _, err := b.Connect(context.Background()); err != context.DeadlineExceeded {
+		t.Fatalf("expected %+v; got %+v", context.DeadlineExceeded, err)
+	}
+	if info := b.Describe()[1]; info.Connects != 0 {
+		t.Fatalf("expected b to not be attempted after the total timeout; got %+v", info)
+	}
+}
+
+// deadlineConnector records how long it was given to connect and fails.
+type deadlineConnector struct {
+	remaining *time.Duration
+}
+
+func (c deadlineConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	deadline, _ := ctx.Deadline()
+	*c.remaining = time.Until(deadline)
+	return nil, errors.New("err")
+}
+
+func (deadlineConnector) Driver() driver.Driver { return nil }
+
+func TestSetDialTimeout(t *testing.T) {
+	b := NewBalancer(WithStrategy(nameStrategy{}), WithDialTimeout(time.Hour))
+	var local, remote, other time.Duration
+	b.Add("local", deadlineConnector{remaining: &local})
+	b.Add("remote", deadlineConnector{remaining: &remote})
+	b.Add("zother", deadlineConnector{remaining: &other})
+	b.SetDialTimeout("local", 10*time.Millisecond)
+	b.SetDialTimeout("remote", time.Second)
+
+	b.Connect(context.Background())
+	if local <= 0 || local > 10*time.Millisecond {
+		t.Fatalf("expected local to get at most 10ms; got %s", local)
+	}
+	if remote <= 10*time.Millisecond || remote > time.Second {
+		t.Fatalf("expected remote to get at most 1s; got %s", remote)
+	}
+	if other <= time.Second {
+		t.Fatalf("expected zother to use the global dial timeout; got %s", other)
+	}
+}
+
+func TestConnectWithTimeout(t *testing.T) {
+	b := NewBalancer()
+	b.Add("slow", slowConnector{})
+
+	start := time.Now()
+	if _, err := b.ConnectWithTimeout(context.Background(), 20*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %+v; got %+v", context.DeadlineExceeded, err)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("expected the attempt to be abandoned at the timeout; took %s", took)
+	}
+	if info := b.Describe()[0]; info.Failures != 1 {
+		t.Fatalf("expected one failed attempt; got %+v", info)
+	}
+}
+
+func TestPredictiveDeadline(t *testing.T) {
+	b := NewBalancer(WithStrategy(nameStrategy{}), WithPredictiveDeadline(true))
+	slowDials := 0
+	b.Add("a-slow", connectorFunc(func(context.Context) (driver.Conn, error) {
+		slowDials++
+		return pingConn{}, nil
+	}))
+	b.Add("b-fast", pingConnector{})
+	setLatency := func(name string, d time.Duration) {
+		c, _ := b.lookup(name)
+		c.mu.Lock()
+		c.mu.latency = d
+		c.mu.Unlock()
+	}
+	setLatency("a-slow", time.Hour)
+	setLatency("b-fast", time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if name := connectedName(t, b, ctx); name != "b-fast" {
+		t.Fatalf("expected the slow connector to be skipped; got %q", name)
+	}
+	if slowDials != 0 {
+		t.Fatalf("expected no dials to the slow connector; got %d", slowDials)
+	}
+
+	// Without a deadline nothing is skipped.
+	if name := connectedName(t, b, context.Background()); name != "a-slow" {
+		t.Fatalf("expected a-slow; got %q", name)
+	}
+
+	setLatency("b-fast", time.Hour)
+	if _, err := b.Connect(ctx); !errors.Is(err, ErrDeadlineTooSoon) {
+		t.Fatalf("expected %+v; got %+v", ErrDeadlineTooSoon, err)
+	}
+}
+
+func TestPredictiveDeadlineClock(t *testing.T) {
+	deadline := time.Now().Add(time.Hour)
+	b := NewBalancer(WithPredictiveDeadline(true))
+	b.now = func() time.Time { return deadline.Add(-10 * time.Millisecond) }
+	b.Add("a", pingConnector{})
+	c, _ := b.lookup("a")
+	c.mu.Lock()
+	c.mu.latency = time.Minute
+	c.mu.Unlock()
+
+	// The time left is measured with the balancer's clock.
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if _, err := b.Connect(ctx); !errors.Is(err, ErrDeadlineTooSoon) {
+		t.Fatalf("expected %+v; got %+v", ErrDeadlineTooSoon, err)
+	}
+}
+
+func TestAdaptiveAttemptTimeout(t *testing.T) {
+	now := time.Unix(100, 0)
+	b := NewBalancer(WithAdaptiveAttemptTimeout(3, 50*time.Millisecond, time.Second))
+	b.now = func() time.Time { return now }
+	latency := 10 * time.Millisecond
+	b.Add("a", connectorFunc(func(context.Context) (driver.Conn, error) {
+		now = now.Add(latency)
+		return pingConn{}, nil
+	}))
+	c, _ := b.lookup("a")
+
+	if d := b.attemptTimeout(c); d != time.Second {
+		t.Fatalf("expected the max before any connects; got %s", d)
+	}
+	for _, step := range []struct {
+		latency, want time.Duration
+	}{
+		{10 * time.Millisecond, 50 * time.Millisecond},
+		{100 * time.Millisecond, 300 * time.Millisecond},
+		{500 * time.Millisecond, time.Second},
+	} {
+		latency = step.latency
+		// Reset the average so it's exactly the new latency.
+		c.mu.Lock()
+		c.mu.latency = 0
+		c.mu.Unlock()
+		for i := 0; i < 3; i++ {
+			connectedName(t, b, context.Background())
+		}
+		if d := b.attemptTimeout(c); d != step.want {
+			t.Fatalf("expected %s with a latency of %s; got %s", step.want, step.latency, d)
+		}
+	}
+
+	b.SetDialTimeout("a", time.Minute)
+	if d := b.attemptTimeout(c); d != time.Minute {
+		t.Fatalf("expected SetDialTimeout to take precedence; got %s", d)
+	}
+}
+
+// stuckConnector ignores the context and blocks until release is closed.
+type stuckConnector struct {
+	release chan struct{}
+	closed  chan struct{}
+}
+
+func (s stuckConnector) Connect(context.Context) (driver.Conn, error) {
+	<-s.release
+	return stuckConn{closed: s.closed}, nil
+}
+
+func (stuckConnector) Driver() driver.Driver { return nil }
+
+type stuckConn struct {
+	pingConn
+	closed chan struct{}
+}
+
+func (c stuckConn) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func TestEnforcedTimeouts(t *testing.T) {
+	stuck := stuckConnector{release: make(chan struct{}), closed: make(chan struct{})}
+	b := NewBalancer(
+		WithStrategy(nameStrategy{}),
+		WithDialTimeout(10*time.Millisecond),
+		WithEnforcedTimeouts(true),
+	)
+	b.Add("a", stuck)
+	b.Add("b", pingConnector{})
+
+	if name := connectedName(t, b, context.Background()); name != "b" {
+		t.Fatalf("expected failover to b; got %s", name)
+	}
+	if info := b.Describe()[0]; info.Failures != 1 || !errors.Is(info.LastError, context.DeadlineExceeded) {
+		t.Fatalf("expected a to time out; got %+v", info)
+	}
+
+	// The abandoned connection is closed once the dial returns.
+	close(stuck.release)
+	select {
+	case <-stuck.closed:
+	case <-time.After(time.Second):
+		t.Fatal("expected the abandoned connection to be closed")
+	}
+}
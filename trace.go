@@ -0,0 +1,35 @@
+package lbsql
+
+import (
+	"context"
+	"time"
+)
+
+// ConnectTrace describes a Connect call for a tracer, see WithConnectTracer.
+type ConnectTrace struct {
+	// Attempts are the connectors attempted, in order.
+	Attempts []Attempt
+	// Duration is how long the call took, including failover.
+	Duration time.Duration
+	// Err is the error the call failed with, or nil if it succeeded.
+	Err error
+}
+
+// WithConnectTracer sets a function that is called with the details of each
+// Connect call once it finishes, for example to record a span. Use
+// WithTraceSampler to only trace some of the calls.
+func WithConnectTracer(tracer func(ctx context.Context, trace ConnectTrace)) Option {
+	return func(b *Balancer) {
+		b.tracer = tracer
+	}
+}
+
+// WithTraceSampler sets a function that is consulted once per Connect call to
+// decide whether the call is traced by the tracer set with WithConnectTracer,
+// which bounds the cost of tracing at high rates. Calls that aren't sampled
+// still update the stats. By default every call is traced.
+func WithTraceSampler(sample func() bool) Option {
+	return func(b *Balancer) {
+		b.traceSampler = sample
+	}
+}
@@ -0,0 +1,55 @@
+package lbsql
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTraceSampler(t *testing.T) {
+	const n, every = 100, 10
+	var calls, traces int
+	b := NewBalancer(
+		WithConnectTracer(func(ctx context.Context, trace ConnectTrace) {
+			traces++
+			if len(trace.Attempts) != 1 || trace.Attempts[0].Name != "a" || trace.Err != nil {
+				t.Fatalf("unexpected trace %+v", trace)
+			}
+		}),
+		WithTraceSampler(func() bool {
+			calls++
+			return calls%every == 0
+		}),
+	)
+	b.Add("a", pingConnector{})
+
+	for i := 0; i < n; i++ {
+		if _, err := b.Connect(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != n {
+		t.Fatalf("expected the sampler to be consulted %d times; got %d", n, calls)
+	}
+	if traces != n/every {
+		t.Fatalf("expected %d traces; got %d", n/every, traces)
+	}
+	if connects := b.Describe()[0].Connects; connects != n {
+		t.Fatalf("expected every connect to be counted; got %d", connects)
+	}
+}
+
+func TestTraceNestedRecorder(t *testing.T) {
+	var traced []Attempt
+	b := NewBalancer(WithConnectTracer(func(ctx context.Context, trace ConnectTrace) {
+		traced = trace.Attempts
+	}))
+	b.Add("a", pingConnector{})
+
+	var attempts []Attempt
+	if _, err := b.Connect(WithAttemptRecorder(context.Background(), &attempts)); err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 1 || len(traced) != 1 {
+		t.Fatalf("expected the attempt in both; got %+v and %+v", attempts, traced)
+	}
+}
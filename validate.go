@@ -0,0 +1,109 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrMissingConnInterface is the error recorded when a connector returns a
+// connection that doesn't implement an interface required by
+// WithRequiredConnInterfaces.
+var ErrMissingConnInterface = errors.New("lbsql: connection is missing a required interface")
+
+// WithConnectValidator sets a function that checks each new connection before
+// Connect returns it, for example by pinging it or running a setup query. If
+// the validator returns an error the connection is closed, the attempt counts
+// as a failed connect and Connect fails over to the next connector.
+func WithConnectValidator(validator func(ctx context.Context, conn driver.Conn) error) Option {
+	return func(b *Balancer) {
+		b.validator = validator
+	}
+}
+
+// WithRequiredConnInterfaces makes Connect reject connections that don't
+// implement all of the given interfaces, each passed as a nil pointer to the
+// interface such as (*driver.QueryerContext)(nil). Rejected connections are
+// closed and count as a failed connect like with WithConnectValidator. It
+// panics if passed anything other than a pointer to an interface.
+func WithRequiredConnInterfaces(ifaces ...any) Option {
+	types := make([]reflect.Type, len(ifaces))
+	for i, iface := range ifaces {
+		t := reflect.TypeOf(iface)
+		if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Interface {
+			panic(fmt.Sprintf("lbsql: WithRequiredConnInterfaces: %T is not a pointer to an interface", iface))
+		}
+		types[i] = t.Elem()
+	}
+	return func(b *Balancer) {
+		b.requiredIfaces = types
+	}
+}
+
+// SetConnInit sets statements to run on every new connection from the named
+// connector before Connect returns it, such as SET application_name. If a
+// statement fails the connection is closed, the attempt counts as a failed
+// connect and Connect fails over to the next connector.
+func (b *Balancer) SetConnInit(name string, stmts []string) {
+	c, ok := b.lookup(name)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mu.initStmts = append([]string(nil), stmts...)
+}
+
+// validate checks a new connection from c and runs its init statements,
+// closing it if it isn't usable.
+func (b *Balancer) validate(ctx context.Context, c *connector, conn driver.Conn) error {
+	for _, t := range b.requiredIfaces {
+		if !reflect.TypeOf(conn).Implements(t) {
+			conn.Close()
+			return fmt.Errorf("%w: %T does not implement %s", ErrMissingConnInterface, conn, t)
+		}
+	}
+	c.mu.Lock()
+	stmts := c.mu.initStmts
+	c.mu.Unlock()
+	for _, stmt := range stmts {
+		if err := execInit(ctx, conn, stmt); err != nil {
+			conn.Close()
+			return fmt.Errorf("lbsql: running init statement %q: %w", stmt, err)
+		}
+	}
+	if b.validator == nil {
+		return nil
+	}
+	if err := b.validator(ctx, conn); err != nil {
+		conn.Close()
+		return fmt.Errorf("lbsql: validating connection: %w", err)
+	}
+	return nil
+}
+
+// execInit runs an init statement on conn, preparing it if conn doesn't
+// implement driver.ExecerContext.
+func execInit(ctx context.Context, conn driver.Conn, stmt string) error {
+	if e, ok := conn.(driver.ExecerContext); ok {
+		_, err := e.ExecContext(ctx, stmt, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+	st, err := conn.Prepare(stmt)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	if sc, ok := st.(driver.StmtExecContext); ok {
+		_, err = sc.ExecContext(ctx, nil)
+		return err
+	}
+	_, err = st.Exec(nil)
+	return err
+}
@@ -0,0 +1,147 @@
+package lbsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type closeConn struct {
+	pingConn
+	name   string
+	closed *int
+}
+
+func (c closeConn) Close() error {
+	*c.closed++
+	return nil
+}
+
+type closeConnector struct {
+	name   string
+	closed int
+}
+
+func (c *closeConnector) Connect(context.Context) (driver.Conn, error) {
+	return closeConn{name: c.name, closed: &c.closed}, nil
+}
+
+func (*closeConnector) Driver() driver.Driver { return nil }
+
+func TestConnectValidator(t *testing.T) {
+	a := &closeConnector{name: "a"}
+	bConn := &closeConnector{name: "b"}
+
+	b := NewBalancer(
+		WithStrategy(nameStrategy{}),
+		WithConnectValidator(func(_ context.Context, conn driver.Conn) error {
+			if conn.(closeConn).name == "a" {
+				return errors.New("not ready")
+			}
+			return nil
+		}),
+	)
+	b.Add("a", a)
+	b.Add("b", bConn)
+
+	c, err := b.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := c.(*conn).Conn.(closeConn).name; name != "b" {
+		t.Fatalf("expected connection to b; got %q", name)
+	}
+	if a.closed != 1 || bConn.closed != 0 {
+		t.Fatalf("expected only a's connection to be closed; got a=%d b=%d", a.closed, bConn.closed)
+	}
+	if info := b.Describe()[0]; info.Failures != 1 || info.Open != 0 {
+		t.Fatalf("expected validation failure to count as a failure; got %+v", info)
+	}
+}
+
+// queryConn is a connection that implements driver.QueryerContext.
+type queryConn struct {
+	pingConn
+}
+
+func (queryConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestRequiredConnInterfaces(t *testing.T) {
+	b := NewBalancer(
+		WithStrategy(nameStrategy{}),
+		WithRequiredConnInterfaces((*driver.QueryerContext)(nil), (*driver.Pinger)(nil)),
+	)
+	a := &closeConnector{name: "a"}
+	b.Add("a", a)
+	b.Add("b", connectorFunc(func(context.Context) (driver.Conn, error) { return queryConn{}, nil }))
+
+	if name := connectedName(t, b, context.Background()); name != "b" {
+		t.Fatalf("expected failover to b; got %s", name)
+	}
+	if a.closed != 1 {
+		t.Fatalf("expected a's connection to be closed; got %d", a.closed)
+	}
+	if info := b.Describe()[0]; info.Failures != 1 || !errors.Is(info.LastError, ErrMissingConnInterface) {
+		t.Fatalf("expected a missing interface failure; got %+v", info)
+	}
+}
+
+func TestRequiredConnInterfacesPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a non-interface")
+		}
+	}()
+	WithRequiredConnInterfaces(queryConn{})
+}
+
+// initConn records the statements executed on it and fails the ones in fail.
+type initConn struct {
+	pingConn
+	stmts *[]string
+	fail  string
+}
+
+func (c initConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if query == c.fail {
+		return nil, errors.New("syntax error")
+	}
+	*c.stmts = append(*c.stmts, query)
+	return driver.ResultNoRows, nil
+}
+
+func TestSetConnInit(t *testing.T) {
+	b := NewBalancer(WithStrategy(nameStrategy{}))
+	var aStmts, bStmts []string
+	b.Add("a", connectorFunc(func(context.Context) (driver.Conn, error) {
+		return initConn{stmts: &aStmts, fail: "SET timezone = 'nowhere'"}, nil
+	}))
+	b.Add("b", connectorFunc(func(context.Context) (driver.Conn, error) {
+		return initConn{stmts: &bStmts}, nil
+	}))
+	init := []string{"SET application_name = 'app'", "SET timezone = 'nowhere'"}
+	b.SetConnInit("a", init)
+	b.SetConnInit("b", init)
+
+	if name := connectedName(t, b, context.Background()); name != "b" {
+		t.Fatalf("expected failover to b; got %s", name)
+	}
+	if !reflect.DeepEqual(bStmts, init) {
+		t.Fatalf("expected %+v to be run; got %+v", init, bStmts)
+	}
+	if info := b.Describe()[0]; info.Failures != 1 {
+		t.Fatalf("expected the init failure to count as a failure; got %+v", info)
+	}
+
+	// Connectors without a connection that can run statements fail.
+	b.Add("c", pingConnector{})
+	b.SetConnInit("c", init)
+	only := b.Subset(func(name string) bool { return name == "c" })
+	if _, err := only.Connect(context.Background()); err == nil {
+		t.Fatal("expected init to fail without Prepare")
+	}
+}
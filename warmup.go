@@ -0,0 +1,28 @@
+package lbsql
+
+// WithWarmup makes connectors start in the Warming state when they're added,
+// excluding them from Connect until they've passed the given number of health
+// probes in a row, see CheckHealth. This keeps traffic off backends that
+// haven't been validated yet.
+func WithWarmup(probes int) Option {
+	return func(b *Balancer) {
+		b.warmupProbes = probes
+	}
+}
+
+// warmLocked records a health probe of a warming connector, finishing its
+// warmup once it has passed enough of them in a row. The connector's mutex
+// must be held.
+func (b *Balancer) warmLocked(c *connector, passed bool) {
+	if !c.mu.warming {
+		return
+	}
+	if !passed {
+		c.mu.warmupPassed = 0
+		return
+	}
+	c.mu.warmupPassed++
+	if c.mu.warmupPassed >= b.warmupProbes {
+		c.mu.warming = false
+	}
+}
@@ -0,0 +1,42 @@
+package lbsql
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWarmup(t *testing.T) {
+	b := NewBalancer(WithWarmup(2))
+	b.Add("a", pingConnector{})
+	ctx := context.Background()
+
+	if _, err := b.Connect(ctx); err != ErrNoHealthyConnectors {
+		t.Fatalf("expected %+v while warming; got %+v", ErrNoHealthyConnectors, err)
+	}
+	if h := b.Describe()[0].Health; h != Warming {
+		t.Fatalf("expected warming; got %s", h)
+	}
+
+	// A failed probe restarts the warmup.
+	if err := b.CheckHealth(ctx); err != nil {
+		t.Fatal(err)
+	}
+	b.SetHealthConnector("a", failConnector{errors.New("down")})
+	b.CheckHealth(ctx)
+	b.SetHealthConnector("a", nil)
+	if err := b.CheckHealth(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if h := b.Describe()[0].Health; h != Warming {
+		t.Fatalf("expected warming after a failed probe; got %s", h)
+	}
+
+	if err := b.CheckHealth(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if h := b.Describe()[0].Health; h != Healthy {
+		t.Fatalf("expected healthy after warming up; got %s", h)
+	}
+	connectedName(t, b, ctx)
+}
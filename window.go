@@ -0,0 +1,61 @@
+package lbsql
+
+import "time"
+
+// defaultErrorWindow is the default window recent failures are counted over.
+const defaultErrorWindow = time.Minute
+
+// windowBuckets is the number of buckets a windowCounter splits its window
+// into.
+const windowBuckets = 6
+
+// WithErrorWindow sets the window ConnectorInfo.RecentFailures counts failed
+// connects over, which is used by LeastErrorsStrategy. The window slides in
+// steps of a sixth of its length. The default is one minute, and a window of
+// zero counts every failure.
+func WithErrorWindow(d time.Duration) Option {
+	return func(b *Balancer) {
+		b.errorWindow = d
+	}
+}
+
+// windowCounter counts events over a sliding window made up of fixed width
+// buckets.
+type windowCounter struct {
+	width   time.Duration
+	buckets [windowBuckets]int64
+	// head is the index of the newest bucket, which ends at end.
+	head int
+	end  time.Time
+}
+
+// advance drops the buckets that have fallen out of the window.
+func (w *windowCounter) advance(now time.Time) {
+	if w.width <= 0 {
+		return
+	}
+	for i := 0; i < windowBuckets && !now.Before(w.end); i++ {
+		w.head = (w.head + 1) % windowBuckets
+		w.buckets[w.head] = 0
+		w.end = w.end.Add(w.width)
+	}
+	if !now.Before(w.end) {
+		w.end = now.Add(w.width)
+	}
+}
+
+// add counts an event at the given time.
+func (w *windowCounter) add(now time.Time) {
+	w.advance(now)
+	w.buckets[w.head]++
+}
+
+// sum returns the number of events within the window.
+func (w *windowCounter) sum(now time.Time) int64 {
+	w.advance(now)
+	var n int64
+	for _, v := range w.buckets {
+		n += v
+	}
+	return n
+}
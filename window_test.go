@@ -0,0 +1,28 @@
+package lbsql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowCounter(t *testing.T) {
+	w := windowCounter{width: time.Second}
+	now := time.Now()
+
+	w.add(now)
+	w.add(now.Add(2 * time.Second))
+	w.add(now.Add(4 * time.Second))
+	if n := w.sum(now.Add(4 * time.Second)); n != 3 {
+		t.Fatalf("expected 3 events; got %d", n)
+	}
+	if n := w.sum(now.Add(7 * time.Second)); n != 2 {
+		t.Fatalf("expected the oldest event to slide out; got %d", n)
+	}
+	if n := w.sum(now.Add(time.Hour)); n != 0 {
+		t.Fatalf("expected no events; got %d", n)
+	}
+	w.add(now.Add(time.Hour))
+	if n := w.sum(now.Add(time.Hour + time.Second)); n != 1 {
+		t.Fatalf("expected 1 event; got %d", n)
+	}
+}